@@ -29,8 +29,8 @@ func (this *Accumulator) CheckMinMax(transitions []interfaces.Univalue) []*Confl
 
 	sort.SliceStable(transitions, func(i, j int) bool {
 		lhv := transitions[i].Value().(interfaces.Type)
-		rhv := transitions[i].Value().(interfaces.Type)
-		return lhv.DeltaSign() != rhv.DeltaSign() && !lhv.DeltaSign()
+		rhv := transitions[j].Value().(interfaces.Type)
+		return lhv.DeltaSign() != rhv.DeltaSign() && !lhv.DeltaSign() // Negative deltas first
 	})
 
 	negatives, positives := this.Categorize(transitions)
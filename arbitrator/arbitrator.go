@@ -5,6 +5,7 @@ import (
 
 	common "github.com/arcology-network/common-lib/common"
 	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
 	"github.com/arcology-network/concurrenturl/indexer"
 	"github.com/arcology-network/concurrenturl/interfaces"
 )
@@ -38,6 +39,20 @@ func (this *Arbitrator) Detect(groupIDs []uint32, newTrans []interfaces.Univalue
 			continue // Only one entry
 		}
 
+		if this.isPartialReadRange(newTrans[ranges[i]:ranges[i+1]]) {
+			if conflict := this.detectPartialReads(groupIDs[ranges[i]:ranges[i+1]], newTrans[ranges[i]:ranges[i+1]]); conflict != nil {
+				conflicts = append(conflicts, conflict)
+			}
+			continue // Length or key existence reads along with the delta writes only
+		}
+
+		if common.LocateFirstIf(newTrans[ranges[i]:ranges[i+1]], func(v interfaces.Univalue) bool { return !v.IsFieldLevel() }) < 0 {
+			if conflict := this.detectFields(groupIDs[ranges[i]:ranges[i+1]], newTrans[ranges[i]:ranges[i+1]]); conflict != nil {
+				conflicts = append(conflicts, conflict)
+			}
+			continue // Field level accesses only
+		}
+
 		offset := int(1)
 		if newTrans[ranges[i]].Writes() == 0 {
 			if newTrans[ranges[i]].IsConcurrentWritable() { // Delta write only
@@ -103,3 +118,109 @@ func (this *Arbitrator) Detect(groupIDs []uint32, newTrans []interfaces.Univalue
 	// }
 	return conflicts
 }
+
+// Only the transactions touching the same fields of a value conflict with each other.
+func (this *Arbitrator) detectFields(groupIDs []uint32, trans []interfaces.Univalue) *Conflict {
+	reads, writes := map[uint32]bool{}, map[uint32]bool{}
+	overlapped := func(indices []uint32, dicts ...map[uint32]bool) bool {
+		for _, idx := range indices {
+			for _, dict := range dicts {
+				if dict[idx] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	conflictTxs, conflictGroupIDs := []uint32{}, []uint32{}
+	for i, v := range trans {
+		if overlapped(v.FieldWrites(), reads, writes) || overlapped(v.FieldReads(), writes) {
+			conflictTxs = append(conflictTxs, v.GetTx())
+			conflictGroupIDs = append(conflictGroupIDs, groupIDs[i])
+			continue
+		}
+
+		common.Foreach(v.FieldReads(), func(idx *uint32, _ int) { reads[*idx] = true })
+		common.Foreach(v.FieldWrites(), func(idx *uint32, _ int) { writes[*idx] = true })
+	}
+
+	return this.accessConflict(trans, conflictGroupIDs, conflictTxs)
+}
+
+func (this *Arbitrator) accessConflict(trans []interfaces.Univalue, groupIDs, txIDs []uint32) *Conflict {
+	if len(txIDs) == 0 {
+		return nil
+	}
+
+	return &Conflict{
+		key:     *trans[0].GetPath(),
+		self:    trans[0].GetTx(),
+		groupID: groupIDs,
+		txIDs:   txIDs,
+		Err:     errors.New(ccurlcommon.WARN_ACCESS_CONFLICT),
+	}
+}
+
+// Some partial reads, and the rest are all delta writes
+func (this *Arbitrator) isPartialReadRange(trans []interfaces.Univalue) bool {
+	return common.LocateFirstIf(trans, func(v interfaces.Univalue) bool { return v.IsPartialRead() }) >= 0 &&
+		common.LocateFirstIf(trans, func(v interfaces.Univalue) bool {
+			return !v.IsPartialRead() && !(v.Reads() == 0 && v.Writes() == 0 && v.DeltaWrites() > 0)
+		}) < 0
+}
+
+// The keys changed by a delta write and if the length has changed, unknown if the delta isn't available.
+func (this *Arbitrator) pathDelta(v interfaces.Univalue) ([]string, bool, bool) {
+	if len(v.KeyWrites()) > 0 {
+		return v.KeyWrites(), v.LengthDelta() != 0, true
+	}
+
+	if path, ok := v.Value().(*commutative.Path); ok && path != nil && path.Delta().(*commutative.PathDelta).Touched() {
+		return append(common.Clone(path.Added()), path.Removed()...), len(path.Added()) != len(path.Removed()), true
+	}
+	return nil, true, false
+}
+
+// A length read only conflicts with the writes changing the length, and an existence read
+// only conflicts with the writes adding or removing the same key.
+func (this *Arbitrator) detectPartialReads(groupIDs []uint32, trans []interfaces.Univalue) *Conflict {
+	lengthRead, keysRead := false, map[string]bool{}
+	lengthChanged, allChanged, keysChanged := false, false, map[string]bool{}
+
+	overlapped := func(keys []string, dict map[string]bool) bool {
+		for _, key := range keys {
+			if dict[key] {
+				return true
+			}
+		}
+		return false
+	}
+
+	conflictTxs, conflictGroupIDs := []uint32{}, []uint32{}
+	for i, v := range trans {
+		if v.IsPartialRead() {
+			if (v.LengthReads() > 0 && lengthChanged) || (len(v.KeyReads()) > 0 && allChanged) || overlapped(v.KeyReads(), keysChanged) {
+				conflictTxs = append(conflictTxs, v.GetTx())
+				conflictGroupIDs = append(conflictGroupIDs, groupIDs[i])
+				continue
+			}
+
+			lengthRead = lengthRead || v.LengthReads() > 0
+			common.Foreach(v.KeyReads(), func(key *string, _ int) { keysRead[*key] = true })
+			continue
+		}
+
+		keys, changesLength, known := this.pathDelta(v)
+		if (changesLength && lengthRead) || (!known && len(keysRead) > 0) || overlapped(keys, keysRead) {
+			conflictTxs = append(conflictTxs, v.GetTx())
+			conflictGroupIDs = append(conflictGroupIDs, groupIDs[i])
+			continue
+		}
+
+		lengthChanged = lengthChanged || changesLength
+		allChanged = allChanged || !known
+		common.Foreach(keys, func(key *string, _ int) { keysChanged[*key] = true })
+	}
+	return this.accessConflict(trans, conflictGroupIDs, conflictTxs)
+}
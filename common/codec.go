@@ -0,0 +1,37 @@
+package common
+
+import (
+	"errors"
+
+	"github.com/arcology-network/common-lib/codec"
+)
+
+var ErrMalformedByteset = errors.New("Error: Malformed byteset")
+
+// CheckByteset checks the count and the offsets in the header of an encoded codec.Byteset or codec.Strings
+// against the buffer length, so the ones from the untrusted sources can be decoded without going out of range.
+func CheckByteset(buffer []byte) error {
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	if len(buffer) < 2*codec.UINT32_LEN {
+		return ErrMalformedByteset
+	}
+
+	count := uint64(codec.Uint32(0).Decode(buffer).(codec.Uint32))
+	headerLen := (count + 1) * codec.UINT32_LEN
+	if headerLen > uint64(len(buffer)) {
+		return ErrMalformedByteset
+	}
+
+	prev := uint64(0)
+	for i := uint64(0); i < count; i++ {
+		offset := uint64(codec.Uint32(0).Decode(buffer[(i+1)*codec.UINT32_LEN:]).(codec.Uint32))
+		if offset < prev || headerLen+offset > uint64(len(buffer)) {
+			return ErrMalformedByteset
+		}
+		prev = offset
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"math"
 	"strings"
 
 	common "github.com/arcology-network/common-lib/common"
@@ -84,6 +85,16 @@ func ParseAccountAddr(acct string) (string, string, string) {
 		acct[ETH10_ACCOUNT_PREFIX_LENGTH+ETH10_ACCOUNT_LENGTH:]
 }
 
+// Number of the levels below the account, "/storage/ctrn-0/elem-0" is 3 levels deep.
+func PathDepth(key string) uint8 {
+	if len(key) <= ETH10_ACCOUNT_FULL_LENGTH {
+		return 0
+	}
+
+	subKey := strings.Trim(key[ETH10_ACCOUNT_FULL_LENGTH:], "/")
+	return uint8(common.Min(strings.Count(subKey, "/")+1, math.MaxUint8))
+}
+
 func UnderNative(key string) string {
 	if len(key) >= ETH10_ACCOUNT_PREFIX_LENGTH+ETH10_ACCOUNT_LENGTH {
 		subKey := key[ETH10_ACCOUNT_PREFIX_LENGTH+ETH10_ACCOUNT_LENGTH:]
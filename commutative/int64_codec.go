@@ -1,6 +1,7 @@
 package commutative
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 
@@ -64,29 +65,51 @@ func (this *Int64) Print() {
 	fmt.Println()
 }
 
+// RLP doesn't support signed integers, the values are stored in the two's complement form.
 func (this *Int64) StorageEncode() []byte {
 	var buffer []byte
 	if this.IsBounded() {
-		buffer, _ = rlp.EncodeToBytes([]interface{}{this.value, this.min, this.max})
+		buffer, _ = rlp.EncodeToBytes([]uint64{uint64(this.value), uint64(this.min), uint64(this.max)})
 	} else {
-		buffer, _ = rlp.EncodeToBytes(this.value)
+		buffer, _ = rlp.EncodeToBytes(uint64(this.value))
 	}
 	return buffer
 }
 
 func (*Int64) StorageDecode(buffer []byte) interface{} {
-	var this *Int64
-	var arr []interface{}
+	this := NewInt64(math.MinInt64, math.MaxInt64).(*Int64)
+
+	arr := make([]uint64, 3)
 	err := rlp.DecodeBytes(buffer, &arr)
-	if err != nil {
-		var value int64
+	if err != nil || len(arr) != 3 {
+		var value uint64
 		if err = rlp.DecodeBytes(buffer, &value); err == nil {
-			this.value = value
+			this.value = int64(value)
 		}
 	} else {
-		this.value = arr[0].(int64)
-		this.min = arr[1].(int64)
-		this.max = arr[2].(int64)
+		this.value = int64(arr[0])
+		this.min = int64(arr[1])
+		this.max = int64(arr[2])
 	}
 	return this
 }
+
+type int64JSON struct {
+	Value int64 `json:"value"`
+	Delta int64 `json:"delta"`
+	Min   int64 `json:"min"`
+	Max   int64 `json:"max"`
+}
+
+func (this *Int64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int64JSON{this.value, this.delta, this.min, this.max})
+}
+
+func (this *Int64) UnmarshalJSON(buffer []byte) error {
+	var v int64JSON
+	if err := json.Unmarshal(buffer, &v); err != nil {
+		return err
+	}
+	this.value, this.delta, this.min, this.max = v.Value, v.Delta, v.Min, v.Max
+	return nil
+}
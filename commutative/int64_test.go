@@ -222,3 +222,17 @@ func TestInt64Codec(t *testing.T) {
 	// 	t.Error("Error: Wrong value ")
 	// }
 }
+
+func TestInt64RlpCodec(t *testing.T) {
+	in := &Int64{-2, 10, -111, 999}
+	out := (&Int64{}).StorageDecode(in.StorageEncode()).(*Int64)
+	if in.value != out.value || in.min != out.min || in.max != out.max {
+		t.Error("Error: Wrong value", out)
+	}
+
+	in = (&Int64{}).New(int64(-7), nil, nil, nil, nil).(*Int64)
+	out = (&Int64{}).StorageDecode(in.StorageEncode()).(*Int64)
+	if out.value != -7 || out.IsBounded() {
+		t.Error("Error: Wrong value", out)
+	}
+}
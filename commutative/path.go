@@ -2,16 +2,22 @@ package commutative
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/arcology-network/common-lib/codec"
 	"github.com/arcology-network/common-lib/common"
 	orderedset "github.com/arcology-network/common-lib/container/set"
 	"github.com/arcology-network/concurrenturl/interfaces"
+	"github.com/google/btree"
 )
 
 type Path struct {
 	value *orderedset.OrderedSet // committed keys + added - removed
 	delta *PathDelta
+	index *btree.BTreeG[string] // Sorted keys, only for the sorted paths
+
+	unsorted bool       // Keys inserted out of order, the value needs resorting
+	sortLock sync.Mutex // The lazy sort runs on reads, which may be concurrent
 }
 
 func NewPath() interfaces.Type {
@@ -29,9 +35,10 @@ func InitNewPaths(newPaths []string) *Path {
 	}
 }
 
-func (this *Path) Length() int                                                { return this.value.Length() }
-func (this *Path) View() *orderedset.OrderedSet                               { return this.value }
-func (this *Path) MemSize() uint32                                            { return codec.Strings(this.value.Keys()).Size() * 2 } // Just an estimate, need to update on fly instead of calculating everytime
+func (this *Path) Length() int                                                { return this.sorted().Length() }
+func (this *Path) Has(key string) bool                                        { return this.sorted().Exists(key) }
+func (this *Path) View() *orderedset.OrderedSet                               { return this.sorted() }
+func (this *Path) MemSize() uint32                                            { return codec.Strings(this.sorted().Keys()).Size() * 2 } // Just an estimate, need to update on fly instead of calculating everytime
 func (this *Path) TypeID() uint8                                              { return PATH }
 func (this *Path) IsSelf(key interface{}) bool                                { return common.IsPath(key.(string)) }
 func (this *Path) CopyTo(v interface{}) (interface{}, uint32, uint32, uint32) { return v, 0, 1, 0 }
@@ -40,7 +47,7 @@ func (this *Path) IsNumeric() bool     { return false }
 func (this *Path) IsCommutative() bool { return true }
 func (this *Path) IsBounded() bool     { return true }
 
-func (this *Path) Value() interface{} { return this.value }
+func (this *Path) Value() interface{} { return this.sorted() }
 func (this *Path) Delta() interface{} { return this.delta }
 func (this *Path) DeltaSign() bool    { return true }
 func (this *Path) Min() interface{}   { return nil }
@@ -49,39 +56,55 @@ func (this *Path) Max() interface{}   { return nil }
 func (this *Path) CloneDelta() interface{} { return this.delta.Clone().(*PathDelta) }
 
 func (this *Path) IsDeltaApplied() bool       { return this.delta.IsEmpty() }
-func (this *Path) SetValue(v interface{})     { this.value = v.(*orderedset.OrderedSet) }
 func (this *Path) ResetDelta()                { this.SetDelta(NewPathDelta([]string{}, []string{})) }
 func (this *Path) SetDelta(v interface{})     { this.delta = v.(*PathDelta) }
 func (this *Path) SetDeltaSign(v interface{}) {}
 func (this *Path) SetMin(v interface{})       {}
 func (this *Path) SetMax(v interface{})       {}
 
+func (this *Path) SetValue(v interface{}) {
+	this.value = v.(*orderedset.OrderedSet)
+	this.reindex() // Keep the sorted paths in order
+}
+
 func (this *Path) Clone() interface{} {
 	meta := &Path{
-		value: this.value.Clone().(*orderedset.OrderedSet),
+		value: this.sorted().Clone().(*orderedset.OrderedSet),
 		delta: this.delta.Clone().(*PathDelta),
 	}
+
+	if this.index != nil {
+		meta.index = this.index.Clone()
+	}
 	return meta
 }
 
 func (this *Path) Equal(other interface{}) bool {
-	return common.EqualIf(this.value, other.(*Path).value, func(v0, v1 *orderedset.OrderedSet) bool { return v0.Equal(v1) }, func(v *orderedset.OrderedSet) bool { return len(v.Keys()) == 0 }) &&
+	return this.IsSorted() == other.(*Path).IsSorted() &&
+		common.EqualIf(this.sorted(), other.(*Path).sorted(), func(v0, v1 *orderedset.OrderedSet) bool { return v0.Equal(v1) }, func(v *orderedset.OrderedSet) bool { return len(v.Keys()) == 0 }) &&
 		common.EqualIf(this.delta, other.(*Path).delta, func(v0, v1 *PathDelta) bool { return v0.Equal(v1) }, func(v *PathDelta) bool { return len(v.Added()) == 0 && len(v.Removed()) == 0 })
 }
 
 func (this *Path) Get() (interface{}, uint32, uint32) {
-	return this.value, 1, common.IfThen(!this.value.Touched(), uint32(0), uint32(1))
+	value := this.sorted()
+	return value, 1, common.IfThen(!value.Touched(), uint32(0), uint32(1))
 	// return this.value.Keys(), 1, common.IfThen(!this.value.Touched(), uint32(0), uint32(1))
 }
 
 // For the codec only
 func (this *Path) New(value, delta, sign, min, max interface{}) interface{} {
-	return &Path{
+	newPath := &Path{
 		value: common.IfThenDo1st(value != nil && value.(*orderedset.OrderedSet) != nil && len(value.(*orderedset.OrderedSet).Keys()) > 0,
 			func() *orderedset.OrderedSet { return value.(*orderedset.OrderedSet) }, orderedset.NewOrderedSet([]string{})),
 		delta: common.IfThenDo1st(delta != nil && delta.(*PathDelta) != nil && delta.(*PathDelta).Touched(),
 			func() *PathDelta { return delta.(*PathDelta) }, NewPathDelta([]string{}, []string{})),
 	}
+
+	if this.IsSorted() { // Inherit the ordering
+		newPath.index = btree.NewOrderedG[string](SORTED_PATH_DEGREE)
+		newPath.reindex()
+	}
+	return newPath
 }
 
 func (this *Path) ApplyDelta(v interface{}) (interfaces.Type, int, error) { // Apply the transitions to the original value
@@ -115,10 +138,16 @@ func (this *Path) ApplyDelta(v interface{}) (interfaces.Type, int, error) { // A
 		})
 	}
 
-	return &Path{
-		orderedset.NewOrderedSet(keys), // committed keys + added - removed
-		NewPathDelta([]string{}, []string{}),
-	}, len(univals), nil
+	newPath := &Path{
+		value: orderedset.NewOrderedSet(keys), // committed keys + added - removed
+		delta: NewPathDelta([]string{}, []string{}),
+	}
+
+	if this.IsSorted() {
+		newPath.index = btree.NewOrderedG[string](SORTED_PATH_DEGREE)
+		newPath.reindex()
+	}
+	return newPath, len(univals), nil
 }
 
 // Write and afflicated operations
@@ -130,7 +159,7 @@ func (this *Path) Set(value interface{}, source interface{}) (interface{}, uint3
 
 	if common.IsPath(targetPath) && len(targetPath) == len(myPath) { // Delete or rewrite the path
 		if value == nil { // Delete the path and all its elements
-			for _, subpath := range this.value.Keys() { // Get all the sub paths
+			for _, subpath := range common.Clone(this.value.Keys()) { // The sub paths will be removed from the keys along the way, recursively for the nested ones
 				writeCache.Write(tx, targetPath+subpath, nil) //FIXME: THIS EMITS SOME ERROR MESSAGEES BUT DON't SEEM TO BE HARMFUL
 			}
 			return this, 0, 1, 0, nil
@@ -146,6 +175,11 @@ func (this *Path) Set(value interface{}, source interface{}) (interface{}, uint3
 
 	if value == nil {
 		this.value.DeleteByKey(subkey) // Delete a key
+		if this.IsSorted() {
+			this.index.Delete(subkey)
+		}
+	} else if this.IsSorted() {
+		this.insertSorted(subkey)
 	} else {
 		this.value.Insert(subkey)
 	}
@@ -159,6 +193,7 @@ func (this *Path) Set(value interface{}, source interface{}) (interface{}, uint3
 func (this *Path) Reset() {
 	this.value = orderedset.NewOrderedSet([]string{})
 	this.delta = NewPathDelta([]string{}, []string{})
+	this.reindex()
 }
 
 func (this *Path) Hash(hasher func([]byte) []byte) []byte {
@@ -166,12 +201,15 @@ func (this *Path) Hash(hasher func([]byte) []byte) []byte {
 }
 
 // For Debug
-func (this *Path) SetSubs(keys []string)    { this.value = orderedset.NewOrderedSet(keys) }
+func (this *Path) SetSubs(keys []string)    { this.SetValue(orderedset.NewOrderedSet(keys)) }
 func (this *Path) SetAdded(keys []string)   { this.delta.addDict = orderedset.NewOrderedSet(keys) }
 func (this *Path) SetRemoved(keys []string) { this.delta.delDict = orderedset.NewOrderedSet(keys) }
 
 func (this *Path) Keys() []string {
-	return common.IfThenDo1st(this.value != nil, func() []string { return this.value.Keys() }, []string{})
+	if value := this.sorted(); value != nil {
+		return value.Keys()
+	}
+	return []string{}
 }
 func (this *Path) Added() []string {
 	return common.IfThenDo1st(this.value != nil, func() []string { return this.delta.Added() }, []string{})
@@ -1,6 +1,7 @@
 package commutative
 
 import (
+	"encoding/json"
 	"fmt"
 
 	codec "github.com/arcology-network/common-lib/codec"
@@ -9,33 +10,39 @@ import (
 
 	// performance "github.com/arcology-network/common-lib/mhasher"
 	orderedset "github.com/arcology-network/common-lib/container/set"
+	"github.com/google/btree"
 )
 
 func (this *Path) HeaderSize() uint32 {
-	return 3 * codec.UINT32_LEN // number of fields + 1
+	return uint32(len(this.fieldSizes())+1) * codec.UINT32_LEN // number of fields + 1
+}
+
+// The sorting flag is only encoded for the sorted paths, so the plain ones keep the original format.
+func (this *Path) fieldSizes() []uint32 {
+	value := this.sorted()
+	sizes := []uint32{
+		common.IfThenDo1st(value != nil, func() uint32 { return value.Size() }, 0),
+		common.IfThenDo1st(this.delta != nil, func() uint32 { return this.delta.Size() }, 0),
+	}
+	return common.IfThenDo1st(this.IsSorted(), func() []uint32 { return append(sizes, codec.Bool(true).Size()) }, sizes)
 }
 
 func (this *Path) Size() uint32 {
-	return this.HeaderSize() +
-		common.IfThenDo1st(this.value != nil, func() uint32 { return this.value.Size() }, 0) +
-		common.IfThenDo1st(this.delta != nil, func() uint32 { return this.delta.Size() }, 0)
+	return this.HeaderSize() + common.Sum(this.fieldSizes())
 }
 
 func (this *Path) Encode() []byte {
 	buffer := make([]byte, this.Size()) //  no need to send the committed keys
-	offset := codec.Encoder{}.FillHeader(buffer,
-		[]uint32{
-			common.IfThenDo1st(this.value != nil, func() uint32 { return this.value.Size() }, 0),
-			common.IfThenDo1st(this.delta != nil, func() uint32 { return this.delta.Size() }, 0),
-		},
-	)
+	offset := codec.Encoder{}.FillHeader(buffer, this.fieldSizes())
 	this.EncodeToBuffer(buffer[offset:])
 	return buffer
 }
 
 func (this *Path) EncodeToBuffer(buffer []byte) int {
-	offset := common.IfThenDo1st(this.value != nil, func() int { return this.value.EncodeToBuffer(buffer) }, 0)
+	value := this.sorted()
+	offset := common.IfThenDo1st(value != nil, func() int { return value.EncodeToBuffer(buffer) }, 0)
 	offset += common.IfThenDo1st(this.delta != nil, func() int { return this.delta.EncodeToBuffer(buffer[offset:]) }, 0)
+	offset += common.IfThenDo1st(this.IsSorted(), func() int { return codec.Bool(true).EncodeToBuffer(buffer[offset:]) }, 0)
 	return offset
 }
 
@@ -45,10 +52,16 @@ func (this *Path) Decode(buffer []byte) interface{} {
 	}
 
 	fields := codec.Byteset{}.Decode(buffer).(codec.Byteset)
-	return &Path{
+	path := &Path{
 		value: orderedset.NewOrderedSet(codec.Strings{}.Decode(fields[0]).(codec.Strings)),
 		delta: NewPathDelta([]string{}, []string{}).Decode(fields[1]).(*PathDelta),
 	}
+
+	if len(fields) > 2 && bool(codec.Bool(false).Decode(fields[2]).(codec.Bool)) {
+		path.index = btree.NewOrderedG[string](SORTED_PATH_DEGREE)
+		path.reindex()
+	}
+	return path
 }
 
 func (this *Path) Print() {
@@ -68,3 +81,35 @@ func (this *Path) StorageDecode(buffer []byte) interface{} {
 	rlp.DecodeBytes(buffer, &decoded)
 	return this.Decode(decoded)
 }
+
+type pathJSON struct {
+	Keys    []string `json:"keys"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Sorted  bool     `json:"sorted,omitempty"`
+}
+
+func (this *Path) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pathJSON{
+		Keys:    this.Keys(),
+		Added:   common.IfThenDo1st(this.delta != nil, func() []string { return this.delta.Added() }, []string{}),
+		Removed: common.IfThenDo1st(this.delta != nil, func() []string { return this.delta.Removed() }, []string{}),
+		Sorted:  this.IsSorted(),
+	})
+}
+
+func (this *Path) UnmarshalJSON(buffer []byte) error {
+	var v pathJSON
+	if err := json.Unmarshal(buffer, &v); err != nil {
+		return err
+	}
+
+	this.value = orderedset.NewOrderedSet(v.Keys)
+	this.delta = NewPathDelta(v.Added, v.Removed)
+	this.index = nil
+	if v.Sorted {
+		this.index = btree.NewOrderedG[string](SORTED_PATH_DEGREE)
+		this.reindex()
+	}
+	return nil
+}
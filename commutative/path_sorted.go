@@ -0,0 +1,112 @@
+package commutative
+
+import (
+	orderedset "github.com/arcology-network/common-lib/container/set"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	"github.com/google/btree"
+)
+
+const SORTED_PATH_DEGREE = 32
+
+// A path keeping its sub keys in the key order instead of the insertion order.
+func NewSortedPath() interfaces.Type {
+	this := NewPath().(*Path)
+	this.index = btree.NewOrderedG[string](SORTED_PATH_DEGREE)
+	return this
+}
+
+func (this *Path) IsSorted() bool { return this.index != nil }
+
+// Sort the keys and rebuild the index, only for the sorted paths.
+func (this *Path) reindex() {
+	if this.index == nil {
+		return
+	}
+
+	this.index.Clear(false)
+	for _, key := range this.value.Keys() {
+		this.index.ReplaceOrInsert(key)
+	}
+	this.value, this.unsorted = orderedset.NewOrderedSet(this.ascend()), false
+}
+
+// The keys inserted out of order are only put in the index, the value is resorted on the next read,
+// so a bulk insert sorts once. The sort is locked since the readers may run concurrently.
+func (this *Path) sorted() *orderedset.OrderedSet {
+	this.sortLock.Lock()
+	defer this.sortLock.Unlock()
+
+	if this.unsorted {
+		this.value, this.unsorted = orderedset.NewOrderedSet(this.ascend()), false
+	}
+	return this.value
+}
+
+func (this *Path) ascend() []string {
+	keys := make([]string, 0, this.index.Len())
+	this.index.Ascend(func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Insert a new key, a key smaller than the current max one leaves the value out of order until the next read.
+func (this *Path) insertSorted(key string) {
+	if maxKey, ok := this.index.Max(); ok && key < maxKey {
+		this.unsorted = true
+	}
+	this.index.ReplaceOrInsert(key)
+	this.value.Insert(key)
+}
+
+// The first key not less than the given one.
+func (this *Path) LowerBound(key string) (string, bool) {
+	if this.index == nil {
+		return "", false
+	}
+
+	found, ok := "", false
+	this.index.AscendGreaterOrEqual(key, func(k string) bool {
+		found, ok = k, true
+		return false
+	})
+	return found, ok
+}
+
+// The first key greater than the given one.
+func (this *Path) UpperBound(key string) (string, bool) {
+	if this.index == nil {
+		return "", false
+	}
+
+	found, ok := "", false
+	this.index.AscendGreaterOrEqual(key, func(k string) bool {
+		if k == key {
+			return true
+		}
+		found, ok = k, true
+		return false
+	})
+	return found, ok
+}
+
+// All the keys in [start, end), an empty end means no upper limit.
+func (this *Path) Range(start, end string) []string {
+	keys := []string{}
+	if this.index == nil {
+		return keys
+	}
+
+	appender := func(k string) bool {
+		keys = append(keys, k)
+		return true
+	}
+
+	if len(end) == 0 {
+		this.index.AscendGreaterOrEqual(start, appender)
+	} else {
+		this.index.AscendRange(start, end, appender)
+	}
+	return keys
+}
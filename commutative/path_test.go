@@ -2,6 +2,7 @@ package commutative
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	common "github.com/arcology-network/common-lib/common"
@@ -88,3 +89,84 @@ func TestCodecPathMeta(t *testing.T) {
 		t.Error("Error: Don't match!!", out.Delta().(*PathDelta).Removed())
 	}
 }
+
+func TestSortedPath(t *testing.T) {
+	in := NewSortedPath().(*Path)
+	in.SetSubs([]string{"e-03", "e-01", "e-05", "e-02"})
+
+	if !common.EqualArray(in.Keys(), []string{"e-01", "e-02", "e-03", "e-05"}) {
+		t.Error("Error: The keys should be sorted", in.Keys())
+	}
+
+	if k, ok := in.LowerBound("e-03"); !ok || k != "e-03" {
+		t.Error("Error: Wrong lower bound", k)
+	}
+
+	if k, ok := in.UpperBound("e-03"); !ok || k != "e-05" {
+		t.Error("Error: Wrong upper bound", k)
+	}
+
+	if _, ok := in.UpperBound("e-05"); ok {
+		t.Error("Error: There should be no upper bound")
+	}
+
+	if keys := in.Range("e-02", "e-05"); !common.EqualArray(keys, []string{"e-02", "e-03"}) {
+		t.Error("Error: Wrong range", keys)
+	}
+
+	if keys := in.Range("e-02", ""); !common.EqualArray(keys, []string{"e-02", "e-03", "e-05"}) {
+		t.Error("Error: Wrong range", keys)
+	}
+
+	out := (&Path{}).Decode(in.Encode()).(*Path)
+	if !out.IsSorted() || !out.Equal(in) {
+		t.Error("Error: Mismatch after decoding")
+	}
+
+	if plain := NewPath().(*Path); len(plain.Encode()) != 3*4+int(plain.value.Size()+plain.delta.Size()) {
+		t.Error("Error: The plain path encoding shouldn't change")
+	}
+}
+
+func TestSortedPathBulkInsert(t *testing.T) {
+	in := NewSortedPath().(*Path)
+	for i := 999; i >= 0; i-- {
+		in.insertSorted(fmt.Sprintf("e-%03d", i))
+	}
+
+	if !in.unsorted {
+		t.Error("Error: Should be resorted lazily")
+	}
+
+	in.value.DeleteByKey("e-500")
+	in.index.Delete("e-500")
+
+	keys := in.Keys()
+	if in.unsorted || len(keys) != 999 || keys[0] != "e-000" || keys[500] != "e-501" || keys[998] != "e-999" {
+		t.Error("Error: The keys should be sorted", len(keys))
+	}
+
+	if !in.Has("e-999") || in.Has("e-500") || in.View().IdxOf("e-501") != 500 {
+		t.Error("Error: Wrong lookups")
+	}
+}
+
+func TestSortedPathConcurrentReads(t *testing.T) {
+	in, other := NewSortedPath().(*Path), NewSortedPath().(*Path)
+	for i := 99; i >= 0; i-- {
+		in.insertSorted(fmt.Sprintf("e-%03d", i))
+		other.insertSorted(fmt.Sprintf("e-%03d", i))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if keys := in.Keys(); len(keys) != 100 || keys[0] != "e-000" || !in.Equal(other) || in.Length() != 100 {
+				t.Error("Error: Wrong keys")
+			}
+		}()
+	}
+	wg.Wait()
+}
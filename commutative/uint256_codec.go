@@ -1,11 +1,13 @@
 package commutative
 
 import (
+	"encoding/json"
 	"math/big"
 
 	codec "github.com/arcology-network/common-lib/codec"
 	"github.com/arcology-network/common-lib/common"
 	"github.com/arcology-network/evm/rlp"
+	uint256 "github.com/holiman/uint256"
 )
 
 func (this *U256) HeaderSize() uint32 {
@@ -91,7 +93,7 @@ func (*U256) StorageDecode(buffer []byte) interface{} {
 
 	var arr []interface{}
 	err := rlp.DecodeBytes(buffer, &arr)
-	if err != nil {
+	if err != nil || len(arr) != 3 {
 		var v2 big.Int
 		if err = rlp.DecodeBytes(buffer, &v2); err == nil {
 			this.value.SetFromBig(&v2)
@@ -103,3 +105,24 @@ func (*U256) StorageDecode(buffer []byte) interface{} {
 	}
 	return this
 }
+
+type u256JSON struct {
+	Value         *uint256.Int `json:"value"`
+	Delta         *uint256.Int `json:"delta"`
+	DeltaPositive bool         `json:"deltaPositive"`
+	Min           *uint256.Int `json:"min"`
+	Max           *uint256.Int `json:"max"`
+}
+
+func (this *U256) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u256JSON{&this.value, &this.delta, this.deltaPositive, &this.min, &this.max})
+}
+
+func (this *U256) UnmarshalJSON(buffer []byte) error {
+	v := u256JSON{new(uint256.Int), new(uint256.Int), true, new(uint256.Int), new(uint256.Int)}
+	if err := json.Unmarshal(buffer, &v); err != nil {
+		return err
+	}
+	this.value, this.delta, this.deltaPositive, this.min, this.max = *v.Value, *v.Delta, v.DeltaPositive, *v.Min, *v.Max
+	return nil
+}
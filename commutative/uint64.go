@@ -12,18 +12,29 @@ import (
 // type Selector []bool
 
 type Uint64 struct {
-	value uint64
-	delta uint64
-	min   uint64
-	max   uint64
+	value         uint64
+	delta         uint64
+	min           uint64
+	max           uint64
+	deltaPositive bool
 }
 
-func NewUnboundedUint64() interfaces.Type         { return &Uint64{min: 0, max: math.MaxInt64} }
-func NewUint64Delta(delta uint64) interfaces.Type { return &Uint64{delta: delta} }
+func NewUnboundedUint64() interfaces.Type {
+	return &Uint64{min: 0, max: math.MaxInt64, deltaPositive: true}
+}
+
+func NewUint64Delta(delta uint64) interfaces.Type {
+	return &Uint64{delta: delta, deltaPositive: true}
+}
+
+// A delta decreasing the value when deltaPositive is false, like a decrement of an inventory counter.
+func NewSignedUint64Delta(delta uint64, deltaPositive bool) interfaces.Type {
+	return &Uint64{delta: delta, deltaPositive: deltaPositive || delta == 0}
+}
 
 func NewBoundedUint64(min, max uint64) interfaces.Type {
 	if max >= min {
-		return &Uint64{min: min, max: max}
+		return &Uint64{min: min, max: max, deltaPositive: true}
 	}
 	return NewUnboundedUint64()
 }
@@ -31,12 +42,13 @@ func NewBoundedUint64(min, max uint64) interfaces.Type {
 func (this *Uint64) Clone() interface{} { return common.New(*this) }
 
 // For the codec only, don't use it for other purposes
-func (this *Uint64) New(value, delta, _, min, max interface{}) interface{} {
+func (this *Uint64) New(value, delta, sign, min, max interface{}) interface{} {
 	return &Uint64{
 		common.IfThenDo1st(value != nil, func() uint64 { return value.(uint64) }, 0),
 		common.IfThenDo1st(delta != nil, func() uint64 { return delta.(uint64) }, 0),
 		common.IfThenDo1st(min != nil, func() uint64 { return min.(uint64) }, 0),
 		common.IfThenDo1st(max != nil, func() uint64 { return max.(uint64) }, math.MaxUint64),
+		common.IfThenDo1st(sign != nil, func() bool { return sign.(bool) }, true),
 	}
 }
 
@@ -44,7 +56,8 @@ func (this *Uint64) Equal(other interface{}) bool {
 	return this.value == other.(*Uint64).value &&
 		this.delta == other.(*Uint64).delta &&
 		this.min == other.(*Uint64).min &&
-		this.max == other.(*Uint64).max
+		this.max == other.(*Uint64).max &&
+		this.deltaPositive == other.(*Uint64).deltaPositive
 }
 
 func (this *Uint64) MemSize() uint32 { return 5 * 8 }
@@ -55,7 +68,7 @@ func (this *Uint64) IsBounded() bool     { return this.min != 0 || this.max != m
 
 func (this *Uint64) Value() interface{} { return this.value }
 func (this *Uint64) Delta() interface{} { return this.delta }
-func (this *Uint64) DeltaSign() bool    { return true }
+func (this *Uint64) DeltaSign() bool    { return this.deltaPositive }
 func (this *Uint64) Min() interface{}   { return this.min }
 func (this *Uint64) Max() interface{}   { return this.max }
 
@@ -65,25 +78,52 @@ func (this *Uint64) IsDeltaApplied() bool    { return this.delta == 0 }
 
 func (this *Uint64) ResetDelta()                { this.SetDelta(common.New[codec.Uint64](0)) }
 func (this *Uint64) SetDelta(v interface{})     { this.delta = v.(uint64) }
-func (this *Uint64) SetDeltaSign(v interface{}) {}
+func (this *Uint64) SetDeltaSign(v interface{}) { this.deltaPositive = v.(bool) }
 func (this *Uint64) SetMin(v interface{})       { this.min = v.(uint64) }
 func (this *Uint64) SetMax(v interface{})       { this.max = v.(uint64) }
 
 func (this *Uint64) TypeID() uint8                                              { return UINT64 }
 func (this *Uint64) IsSelf(key interface{}) bool                                { return true }
 func (this *Uint64) CopyTo(v interface{}) (interface{}, uint32, uint32, uint32) { return v, 0, 1, 0 }
-func (this *Uint64) Reset()                                                     { this.delta = 0 }
+func (this *Uint64) Reset()                                                     { this.delta, this.deltaPositive = 0, true }
 
 func (this *Uint64) Get() (interface{}, uint32, uint32) {
-	return this.value + this.delta, 1, common.IfThen(this.delta == 0, uint32(0), uint32(1))
+	if this.delta == 0 {
+		return this.value, 1, 0
+	}
+	return common.IfThen(this.deltaPositive, this.value+this.delta, this.value-this.delta), 1, 1
+}
+
+// Accumulate two signed deltas, the sign is positive when they cancel out each other.
+func (this *Uint64) accumulate(lhv uint64, lhvSign bool, rhv uint64, rhvSign bool) (uint64, bool) {
+	if lhvSign == rhvSign {
+		return lhv + rhv, lhvSign
+	}
+
+	if lhv <= rhv {
+		return rhv - lhv, common.IfThen(lhv == rhv, true, rhvSign)
+	}
+	return lhv - rhv, lhvSign
 }
 
 func (this *Uint64) Set(v interface{}, source interface{}) (interface{}, uint32, uint32, uint32, error) {
-	if (this.max < v.(*Uint64).delta) || (this.max-v.(*Uint64).delta < this.value+this.delta) {
-		return this, 0, 1, 0, errors.New("Error: Value out of range!!")
+	newDelta := v.(*Uint64)
+	if newDelta.delta == 0 { // Still a delta write, like before the signed deltas
+		return this, 0, 0, 1, nil
+	}
+
+	current, _, _ := this.Get()
+	if newDelta.deltaPositive {
+		if (this.max < newDelta.delta) || (this.max-newDelta.delta < current.(uint64)) {
+			return this, 0, 1, 0, errors.New("Error: Value out of range!!")
+		}
+	} else {
+		if (current.(uint64) < newDelta.delta) || (current.(uint64)-newDelta.delta < this.min) {
+			return this, 0, 1, 0, errors.New("Error: Value underflowed!!")
+		}
 	}
 
-	this.delta += v.(*Uint64).delta
+	this.delta, this.deltaPositive = this.accumulate(this.delta, this.deltaPositive, newDelta.delta, newDelta.deltaPositive)
 	return this, 0, 0, 1, nil
 }
 
@@ -114,8 +154,9 @@ func (this *Uint64) ApplyDelta(v interface{}) (interfaces.Type, int, error) {
 		return nil, 0, errors.New("Error: Nil value")
 	}
 
-	this.value += this.delta
-	this.delta = 0
+	newValue, _, _ := this.Get()
+	this.value = newValue.(uint64)
+	this.delta, this.deltaPositive = 0, true
 	return this, len(vec), nil
 }
 
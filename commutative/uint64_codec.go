@@ -1,6 +1,7 @@
 package commutative
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/big"
@@ -11,28 +12,27 @@ import (
 )
 
 func (this *Uint64) HeaderSize() uint32 {
-	return 5 * codec.UINT32_LEN //static size only , no header needed,
+	return uint32(len(this.fieldSizes())+1) * codec.UINT32_LEN // number of fields + 1
+}
+
+// The delta sign is only encoded for the negative deltas, so the positive ones keep the original format.
+func (this *Uint64) fieldSizes() []uint32 {
+	sizes := []uint32{
+		common.IfThen(this.value != 0, uint32(8), 0),
+		common.IfThen(this.delta != 0, uint32(8), 0),
+		common.IfThen(this.min != 0, uint32(8), 0),
+		common.IfThen(this.max != math.MaxUint64, uint32(8), 0),
+	}
+	return common.IfThenDo1st(!this.deltaPositive, func() []uint32 { return append(sizes, codec.Bool(false).Size()) }, sizes)
 }
 
 func (this *Uint64) Size() uint32 {
-	return this.HeaderSize() +
-		common.IfThen(this.value != 0, uint32(8), 0) +
-		common.IfThen(this.delta != 0, uint32(8), 0) +
-		common.IfThen(this.min != 0, uint32(8), 0) +
-		common.IfThen(this.max != math.MaxUint64, uint32(8), 0)
+	return this.HeaderSize() + common.Sum(this.fieldSizes())
 }
 
 func (this *Uint64) Encode() []byte {
 	buffer := make([]byte, this.Size())
-	offset := codec.Encoder{}.FillHeader(
-		buffer,
-		[]uint32{
-			common.IfThen(this.value != 0, uint32(8), 0),
-			common.IfThen(this.delta != 0, uint32(8), 0),
-			common.IfThen(this.min != 0, uint32(8), 0),
-			common.IfThen(this.max != math.MaxUint64, uint32(8), 0),
-		},
-	)
+	offset := codec.Encoder{}.FillHeader(buffer, this.fieldSizes())
 	this.EncodeToBuffer(buffer[offset:])
 	return buffer
 }
@@ -42,6 +42,7 @@ func (this *Uint64) EncodeToBuffer(buffer []byte) int {
 	offset += common.IfThenDo1st(this.delta != 0, func() int { return codec.Uint64(this.delta).EncodeToBuffer(buffer[offset:]) }, 0)
 	offset += common.IfThenDo1st(this.min != 0, func() int { return codec.Uint64(this.min).EncodeToBuffer(buffer[offset:]) }, 0)
 	offset += common.IfThenDo1st(this.max != math.MaxUint64, func() int { return codec.Uint64(this.max).EncodeToBuffer(buffer[offset:]) }, 0)
+	offset += common.IfThenDo1st(!this.deltaPositive, func() int { return codec.Bool(false).EncodeToBuffer(buffer[offset:]) }, 0)
 	return offset
 }
 
@@ -55,11 +56,12 @@ func (this *Uint64) Decode(buffer []byte) interface{} {
 	this.delta = uint64(codec.Uint64(0).Decode(fields[1]).(codec.Uint64))
 	this.min = uint64(codec.Uint64(0).Decode(fields[2]).(codec.Uint64))
 	this.max = uint64(codec.Uint64(math.MaxUint64).Decode(fields[3]).(codec.Uint64))
+	this.deltaPositive = len(fields) <= 4 || bool(codec.Bool(true).Decode(fields[4]).(codec.Bool))
 	return this
 }
 
 func (this *Uint64) Print() {
-	fmt.Println(" Value: ", this.value, "Delta: ", this.delta)
+	fmt.Println(" Value: ", this.value, "Delta: ", this.delta, "Delta Sign: ", this.deltaPositive)
 }
 
 func (this *Uint64) StorageEncode() []byte {
@@ -78,7 +80,7 @@ func (*Uint64) StorageDecode(buffer []byte) interface{} {
 
 	arr := make([]*big.Int, 3)
 	err := rlp.DecodeBytes(buffer, &arr)
-	if err != nil {
+	if err != nil || len(arr) != 3 {
 		var value big.Int
 		if err = rlp.DecodeBytes(buffer, &value); err == nil {
 			this.value = value.Uint64()
@@ -90,3 +92,24 @@ func (*Uint64) StorageDecode(buffer []byte) interface{} {
 	}
 	return this
 }
+
+type uint64JSON struct {
+	Value         uint64 `json:"value"`
+	Delta         uint64 `json:"delta"`
+	DeltaPositive bool   `json:"deltaPositive"`
+	Min           uint64 `json:"min"`
+	Max           uint64 `json:"max"`
+}
+
+func (this *Uint64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(uint64JSON{this.value, this.delta, this.deltaPositive, this.min, this.max})
+}
+
+func (this *Uint64) UnmarshalJSON(buffer []byte) error {
+	var v uint64JSON
+	if err := json.Unmarshal(buffer, &v); err != nil {
+		return err
+	}
+	this.value, this.delta, this.deltaPositive, this.min, this.max = v.Value, v.Delta, v.DeltaPositive, v.Min, v.Max
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"math"
 	"testing"
 	"time"
+
+	codec "github.com/arcology-network/common-lib/codec"
 )
 
 func TestNewUint64(t *testing.T) {
@@ -118,7 +120,7 @@ func TestUint64Codec2(t *testing.T) {
 	// min := uint64(111)
 	// max := uint64(999)
 
-	in := &Uint64{2, 10, 111, 999}
+	in := &Uint64{2, 10, 111, 999, true}
 
 	t0 := time.Now()
 	buffer := in.Encode()
@@ -145,7 +147,7 @@ func TestUint64Codec2(t *testing.T) {
 		t.Error("Don't match")
 	}
 
-	in = &Uint64{val, del, 0, math.MaxUint64}
+	in = &Uint64{val, del, 0, math.MaxUint64, true}
 
 	buffer = in.Encode()
 	out = (&Uint64{}).Decode(buffer).(*Uint64)
@@ -180,7 +182,7 @@ func TestUint64Codec2(t *testing.T) {
 }
 
 func TestUint64RlpCodec(t *testing.T) {
-	in := &Uint64{2, 10, 111, 999}
+	in := &Uint64{2, 10, 111, 999, true}
 
 	t0 := time.Now()
 	buffer := in.StorageEncode()
@@ -194,3 +196,41 @@ func TestUint64RlpCodec(t *testing.T) {
 		t.Error("Wrong value")
 	}
 }
+
+func TestUint64SignedDelta(t *testing.T) {
+	v := NewBoundedUint64(2, 100).(*Uint64)
+	v.SetValue(uint64(10))
+
+	if _, _, _, _, err := v.Set(NewSignedUint64Delta(5, false), nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, _, _, _, err := v.Set(NewSignedUint64Delta(2, true), nil); err != nil {
+		t.Error(err)
+	}
+
+	if final, _, _ := v.Get(); final.(uint64) != 7 || v.DeltaSign() || v.delta != 3 {
+		t.Error("Error: Wrong value", final)
+	}
+
+	if _, _, _, _, err := v.Set(NewSignedUint64Delta(6, false), nil); err == nil {
+		t.Error("Error: Should be lower than the min")
+	}
+
+	if _, _, _, _, err := v.Set(NewSignedUint64Delta(3, true), nil); err != nil || !v.DeltaSign() || v.delta != 0 {
+		t.Error("Error: The deltas should cancel out each other", err)
+	}
+
+	if _, reads, writes, deltaWrites, err := v.Set(NewUint64Delta(0), nil); err != nil || reads != 0 || writes != 0 || deltaWrites != 1 {
+		t.Error("Error: A zero delta should still be recorded as a delta write", deltaWrites, err)
+	}
+
+	out := (&Uint64{}).Decode(NewSignedUint64Delta(9, false).Encode()).(*Uint64)
+	if out.delta != 9 || out.DeltaSign() {
+		t.Error("Error: The delta sign is lost")
+	}
+
+	if fields := (codec.Byteset{}).Decode(NewUint64Delta(9).Encode()).(codec.Byteset); len(fields) != 4 {
+		t.Error("Error: The positive deltas should keep the original format")
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	common "github.com/arcology-network/common-lib/common"
 	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	"github.com/arcology-network/concurrenturl/commutative"
 	"github.com/arcology-network/concurrenturl/interfaces"
 	univalue "github.com/arcology-network/concurrenturl/univalue"
 )
@@ -15,13 +16,15 @@ type DeltaSequence struct {
 	transitions []interfaces.Univalue
 	lock        sync.RWMutex
 	rawBytes    interface{}
+	store       interfaces.Datastore
 }
 
 func NewDeltaSequence(key string, indexer *Importer) *DeltaSequence {
 	return &DeltaSequence{
 		key:         key,
 		transitions: make([]interfaces.Univalue, 0, 16),
-		rawBytes:    common.FilterFirst(indexer.store.Retrive(key, nil)),
+		rawBytes:    common.FilterFirst(indexer.store.Retrive(key, common.IfThen(common.IsPath(key), interface{}(new(commutative.Path)), nil))), // Path transitions only carry the deltas
+		store:       indexer.store,
 		// initial: (&univalue.Univalue{}).Init(ccurlcommon.SYSTEM, key, 0, 0, 0, encoded, indexer.Store()),
 	}
 }
@@ -72,6 +75,17 @@ func (this *DeltaSequence) Finalize() *univalue.Univalue {
 			v := finalized.Value().(interfaces.Type).StorageDecode(encoded).(interfaces.Type).Value()
 			finalized.Value().(interfaces.Type).SetValue(v)
 		}
+
+		if committed, ok := this.rawBytes.(*commutative.Path); ok && common.IsType[*commutative.Path](finalized.Value()) { // Start from the committed keys
+			finalized.Value().(interfaces.Type).SetValue(committed.Value())
+		}
+	}
+
+	// A field level update starts from the committed value, not the snapshot it was made from.
+	if rebaser, ok := finalized.Value().(interface{ Rebase(interfaces.Type) }); ok && this.store != nil {
+		if committed, _ := this.store.Retrive(this.key, finalized.Value()); committed != nil {
+			rebaser.Rebase(committed.(interfaces.Type))
+		}
 	}
 
 	if err := finalized.ApplyDelta(this.transitions[1:]); err != nil {
@@ -2,7 +2,9 @@ package indexer
 
 import (
 	"github.com/arcology-network/common-lib/common"
+	"github.com/arcology-network/concurrenturl/commutative"
 	"github.com/arcology-network/concurrenturl/interfaces"
+	univalue "github.com/arcology-network/concurrenturl/univalue"
 )
 
 type IPCAccess struct {
@@ -20,9 +22,14 @@ func (this IPCAccess) From(v interfaces.Univalue) interface{} {
 	}
 
 	value := v.Value().(interfaces.Type)
-	return v.New(
+	converted := v.New(
 		v.GetUnimeta(),
 		common.IfThen(value.IsCommutative() && value.IsNumeric(), value, nil), // commutative but not meta, for the accumulator
 		[]byte{},
-	)
+	).(interfaces.Univalue)
+
+	if path, ok := value.(*commutative.Path); ok && v.DeltaWrites() > 0 { // The path value is dropped, keep the changed keys for the partial reads
+		converted.GetUnimeta().(*univalue.Unimeta).SetKeyWrites(path.Added(), path.Removed())
+	}
+	return converted
 }
@@ -20,7 +20,7 @@ type Importer struct {
 	keyBuffer []string      // Keys updated in the cycle
 	valBuffer []interface{} // Value updated in the cycle
 	seqPool   *mempool.Mempool
-	uniPool   *mempool.Mempool
+	uniPool   *UnivaluePool
 }
 
 func NewImporter(store interfaces.Datastore, platform interfaces.Platform, args ...interface{}) *Importer {
@@ -36,9 +36,7 @@ func NewImporter(store interfaces.Datastore, platform interfaces.Platform, args
 		return NewDeltaSequence("", nil)
 	})
 
-	importer.uniPool = mempool.NewMempool("importer-univalue", func() interface{} {
-		return new(univalue.Univalue)
-	})
+	importer.uniPool = NewUnivaluePool("importer-univalue")
 	return &importer
 }
 
@@ -56,6 +54,13 @@ func (this *Importer) NewUnivalue() *univalue.Univalue {
 	return v
 }
 
+func (this *Importer) UnivaluePool() *UnivaluePool { return this.uniPool }
+
+// Decode the transitions with the pooled univalues, they will be reclaimed when the importer is cleared.
+func (this *Importer) Decode(buffer []byte) (Univalues, error) {
+	return Univalues{}.DecodeV2(buffer, this.uniPool.Get, this.uniPool.Put)
+}
+
 func (this *Importer) IfExists(key string) bool {
 	if _, ok := this.deltaDict.Get(key); !ok {
 		return this.store.IfExists(key)
@@ -176,11 +181,7 @@ func (this *Importer) Clear() {
 		obj.(*DeltaSequence).Reclaim()
 	})
 
-	this.uniPool.ForEachAllocated(func(obj interface{}) {
-		obj.(*univalue.Univalue).Reclaim()
-	})
-
 	this.seqPool.ReclaimRecursive()
-	this.uniPool.ReclaimRecursive()
+	this.uniPool.Reclaim()
 	this.store.Clear()
 }
@@ -0,0 +1,115 @@
+package indexer
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	mempool "github.com/arcology-network/common-lib/mempool"
+	merkle "github.com/arcology-network/common-lib/merkle"
+	"github.com/arcology-network/concurrenturl/interfaces"
+)
+
+const COMMITMENT_BRANCHES = 16
+
+// Canonical sorts the univalues by tx, path and then the encoding, so the order no longer
+// depends on how they were exported.
+func (this Univalues) Canonical() Univalues {
+	encoded := make([][]byte, len(this))
+	for i, v := range this {
+		encoded[i] = v.Encode()
+	}
+
+	indices := make([]int, len(this))
+	for i := range indices {
+		indices[i] = i
+	}
+
+	sort.SliceStable(indices, func(i, j int) bool {
+		lhv, rhv := this[indices[i]], this[indices[j]]
+		if lhv.GetTx() != rhv.GetTx() {
+			return lhv.GetTx() < rhv.GetTx()
+		}
+
+		if *lhv.GetPath() != *rhv.GetPath() {
+			return *lhv.GetPath() < *rhv.GetPath()
+		}
+		return bytes.Compare(encoded[indices[i]], encoded[indices[j]]) < 0
+	})
+
+	sorted := make([]interfaces.Univalue, len(this))
+	for i, idx := range indices {
+		sorted[i] = this[idx]
+	}
+	return sorted
+}
+
+// TransitionCommitment is a Merkle commitment over the ITCTransitions of a single transaction,
+// so the committer can check the transitions received from the executors.
+type TransitionCommitment struct {
+	tx     uint32
+	leaves [][]byte
+	merkle *merkle.Merkle
+}
+
+// The transitions belonging to other transactions are ignored.
+func NewTransitionCommitment(tx uint32, transitions []interfaces.Univalue) *TransitionCommitment {
+	selected := []interfaces.Univalue{}
+	for _, v := range transitions {
+		if v != nil && v.GetTx() == tx {
+			selected = append(selected, v)
+		}
+	}
+
+	leaves := make([][]byte, 0, len(selected))
+	for _, v := range Univalues(selected).Canonical() {
+		leaves = append(leaves, v.Encode())
+	}
+
+	commitment := &TransitionCommitment{
+		tx:     tx,
+		leaves: leaves,
+		merkle: merkle.NewMerkle(COMMITMENT_BRANCHES, merkle.Concatenator{}, merkle.Keccak256{}),
+	}
+	commitment.merkle.Init(leaves, mempool.NewMempool("node", func() interface{} { return merkle.NewNode() }))
+	return commitment
+}
+
+// Build a commitment for every transaction in the transitions.
+func CommitTransitions(transitions []interfaces.Univalue) map[uint32]*TransitionCommitment {
+	commitments := map[uint32]*TransitionCommitment{}
+	for _, tx := range Univalues(transitions).UniqueTXs() {
+		commitments[tx] = NewTransitionCommitment(tx, transitions)
+	}
+	return commitments
+}
+
+func (this *TransitionCommitment) Tx() uint32   { return this.tx }
+func (this *TransitionCommitment) Length() int  { return len(this.leaves) }
+func (this *TransitionCommitment) Root() []byte { return this.merkle.GetRoot() }
+
+// Prove returns the sibling hashes from the transition up to the root.
+func (this *TransitionCommitment) Prove(transition interfaces.Univalue) ([][][]byte, error) {
+	if transition == nil || transition.GetTx() != this.tx {
+		return nil, errors.New("Error: The transition doesn't belong to the transaction")
+	}
+
+	encoded := transition.Encode()
+	for _, leaf := range this.leaves {
+		if bytes.Equal(leaf, encoded) {
+			_, proof := this.merkle.NodesToHashes(this.merkle.GetProofNodes(encoded))
+			return proof, nil
+		}
+	}
+	return nil, errors.New("Error: The transition isn't in the commitment")
+}
+
+// VerifyTransition checks if the transition is included in the commitment with the root.
+func VerifyTransition(root []byte, transition interfaces.Univalue, proof [][][]byte) bool {
+	if transition == nil || len(root) == 0 {
+		return false
+	}
+
+	verifier := merkle.NewMerkle(COMMITMENT_BRANCHES, merkle.Concatenator{}, merkle.Keccak256{})
+	return verifier.Verify(proof, root, merkle.Keccak256{}.Hash(transition.Encode()))
+}
@@ -0,0 +1,80 @@
+package indexer
+
+import (
+	"sync"
+
+	"github.com/arcology-network/common-lib/mempool"
+	univalue "github.com/arcology-network/concurrenturl/univalue"
+)
+
+// A pool of the univalues for decoding the transitions. The objects handed out are all
+// recycled at once by Reclaim, normally after the transitions have been committed.
+type UnivaluePool struct {
+	pool  *mempool.Mempool
+	free  []*univalue.Univalue
+	inUse map[*univalue.Univalue]struct{}
+	guard sync.Mutex
+}
+
+func NewUnivaluePool(id string) *UnivaluePool {
+	this := &UnivaluePool{
+		free:  []*univalue.Univalue{},
+		inUse: map[*univalue.Univalue]struct{}{},
+	}
+
+	this.pool = mempool.NewMempool(id, func() interface{} {
+		if len(this.free) == 0 {
+			return new(univalue.Univalue)
+		}
+
+		v := this.free[len(this.free)-1]
+		this.free = this.free[:len(this.free)-1]
+		return v
+	})
+	return this
+}
+
+func (this *UnivaluePool) Get() interface{} {
+	this.guard.Lock()
+	defer this.guard.Unlock()
+
+	v := this.pool.Get().(*univalue.Univalue)
+	this.inUse[v] = struct{}{}
+	return v
+}
+
+// Return a single univalue to the pool before the others, putting the same object twice has no effect.
+func (this *UnivaluePool) Put(v interface{}) {
+	this.guard.Lock()
+	defer this.guard.Unlock()
+
+	if _, ok := this.inUse[v.(*univalue.Univalue)]; ok {
+		delete(this.inUse, v.(*univalue.Univalue))
+		this.recycle(v.(*univalue.Univalue))
+	}
+}
+
+// Drop all the references, including the one to the input buffer.
+func (this *UnivaluePool) recycle(v *univalue.Univalue) {
+	*v = univalue.Univalue{}
+	this.free = append(this.free, v)
+}
+
+// Recycle all the univalues handed out, they shouldn't be used afterwards.
+func (this *UnivaluePool) Reclaim() {
+	this.guard.Lock()
+	defer this.guard.Unlock()
+
+	for v := range this.inUse {
+		this.recycle(v)
+	}
+	this.inUse = map[*univalue.Univalue]struct{}{}
+	this.pool.Reclaim()
+}
+
+// The numbers of the univalues in use and available for reuse.
+func (this *UnivaluePool) Stats() (int, int) {
+	this.guard.Lock()
+	defer this.guard.Unlock()
+	return len(this.inUse), len(this.free)
+}
@@ -5,12 +5,13 @@ import (
 
 	"github.com/arcology-network/common-lib/codec"
 	"github.com/arcology-network/common-lib/common"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
 	"github.com/arcology-network/concurrenturl/interfaces"
 	univalue "github.com/arcology-network/concurrenturl/univalue"
 )
 
 func (this Univalues) Size() int {
-	size := (len(this) + 1) * codec.UINT32_LEN
+	size := VERSION_HEADER_LEN + (len(this)+1)*codec.UINT32_LEN
 	for _, v := range this {
 		size += int(v.Size())
 	}
@@ -26,6 +27,13 @@ func (this Univalues) Sizes() []int {
 }
 
 func (this Univalues) Encode(selector ...interface{}) []byte {
+	buffer := this.encode(VERSION_HEADER_LEN)
+	this.FillVersionHeader(buffer, CODEC_VERSION)
+	return buffer
+}
+
+// encode writes the unversioned layout, leaving the first reserved bytes for the header.
+func (this Univalues) encode(reserved int) []byte {
 	lengths := make([]uint32, len(this))
 	worker := func(start, end, index int, args ...interface{}) {
 		for i := start; i < end; i++ {
@@ -42,25 +50,75 @@ func (this Univalues) Encode(selector ...interface{}) []byte {
 	}
 
 	headerLen := uint32((len(this) + 1) * codec.UINT32_LEN)
-	buffer := make([]byte, headerLen+offsets[len(offsets)-1])
+	buffer := make([]byte, reserved+int(headerLen+offsets[len(offsets)-1]))
+	body := buffer[reserved:]
 
-	codec.Uint32(len(this)).EncodeToBuffer(buffer)
+	codec.Uint32(len(this)).EncodeToBuffer(body)
 	worker = func(start, end, index int, args ...interface{}) {
 		for i := start; i < end; i++ {
-			codec.Uint32(offsets[i]).EncodeToBuffer(buffer[(i+1)*codec.UINT32_LEN:])
-			this[i].(interfaces.Univalue).EncodeToBuffer(buffer[headerLen+offsets[i]:])
+			codec.Uint32(offsets[i]).EncodeToBuffer(body[(i+1)*codec.UINT32_LEN:])
+			this[i].(interfaces.Univalue).EncodeToBuffer(body[headerLen+offsets[i]:])
 		}
 	}
 	common.ParallelWorker(len(this), 6, worker)
 	return buffer
 }
 
-func (Univalues) Decode(bytes []byte) interface{} {
+// Decode returns the error instead of the univalues when the batch can't be decoded, TryDecode separates them.
+func (this Univalues) Decode(bytes []byte) interface{} {
+	univalues, err := this.TryDecode(bytes)
+	if err != nil {
+		return err
+	}
+	return univalues
+}
+
+// Check all the univalues before decoding them in parallel, so a malformed one doesn't panic in a worker.
+func (this Univalues) checkElements(buffers [][]byte) error {
+	for i := range buffers {
+		if err := (&univalue.Univalue{}).CheckEncoding(buffers[i]); err != nil {
+			return fmt.Errorf("%w at %d", err, i)
+		}
+	}
+	return nil
+}
+
+// TryDecode returns an error when the batch version isn't supported or the batch is malformed.
+func (this Univalues) TryDecode(bytes []byte) (Univalues, error) {
 	if len(bytes) == 0 {
-		return nil
+		return nil, nil
+	}
+
+	_, body, err := this.Version(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return this.decode(body)
+}
+
+func (this Univalues) decode(bytes []byte) (Univalues, error) {
+	if this.isCompressed(bytes) {
+		plain, paths, err := this.decompress(bytes)
+		if err != nil || len(paths) == 0 {
+			return Univalues{}, err
+		}
+
+		univalues, err := this.decode(plain)
+		if err != nil {
+			return nil, err
+		}
+		return univalues.setPaths(paths)
+	}
+
+	if err := ccurlcommon.CheckByteset(bytes); err != nil {
+		return nil, err
 	}
 
 	buffers := [][]byte(codec.Byteset{}.Decode(bytes).(codec.Byteset))
+	if err := this.checkElements(buffers); err != nil {
+		return nil, err
+	}
+
 	univalues := make([]interfaces.Univalue, len(buffers))
 	worker := func(start, end, index int, args ...interface{}) {
 		for i := start; i < end; i++ {
@@ -69,27 +127,81 @@ func (Univalues) Decode(bytes []byte) interface{} {
 		}
 	}
 	common.ParallelWorker(len(buffers), 6, worker)
-	return Univalues(univalues)
+	return Univalues(univalues), nil
 }
 
-// func (Univalues) DecodeV2(bytesset [][]byte, get func() interface{}, put func(interface{})) Univalues {
-// 	univalues := make([]interfaces.Univalue, len(bytesset))
-// 	for i := range bytesset {
-// 		v := get().(*Univalue)
-// 		v.reclaimFunc = put
-// 		v.Decode(bytesset[i])
-// 		univalues[i] = v
-// 	}
-// 	return Univalues(univalues)
-// }
+// Decode with the univalues from a pool, the byte fields reference the input buffer instead of
+// making copies, so the buffer must stay unchanged until the univalues are reclaimed.
+func (this Univalues) DecodeV2(bytes []byte, get func() interface{}, put func(interface{})) (Univalues, error) {
+	if len(bytes) == 0 {
+		return nil, nil
+	}
+
+	_, body, err := this.Version(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return this.decodeV2(body, get, put)
+}
+
+func (this Univalues) decodeV2(bytes []byte, get func() interface{}, put func(interface{})) (Univalues, error) {
+	if this.isCompressed(bytes) {
+		plain, paths, err := this.decompress(bytes)
+		if err != nil || len(paths) == 0 {
+			return Univalues{}, err
+		}
+
+		univalues, err := this.decodeV2(plain, get, put)
+		if err != nil {
+			return nil, err
+		}
+		return univalues.setPaths(paths)
+	}
+
+	if err := ccurlcommon.CheckByteset(bytes); err != nil {
+		return nil, err
+	}
+
+	buffers := [][]byte(codec.Byteset{}.Decode(bytes).(codec.Byteset))
+	if err := this.checkElements(buffers); err != nil {
+		return nil, err
+	}
+
+	pooled := make([]*univalue.Univalue, len(buffers))
+	for i := range pooled {
+		pooled[i] = get().(*univalue.Univalue)
+	}
+
+	univalues := make([]interfaces.Univalue, len(buffers))
+	worker := func(start, end, index int, args ...interface{}) {
+		for i := start; i < end; i++ {
+			univalues[i] = pooled[i].DecodeTo(buffers[i]).SetReclaimFunc(put)
+		}
+	}
+	common.ParallelWorker(len(buffers), 6, worker)
+	return Univalues(univalues), nil
+}
 
 func (this Univalues) GobEncode() ([]byte, error) {
 	return this.Encode(), nil
 }
 
 func (this *Univalues) GobDecode(data []byte) error {
-	v := this.Decode(data)
-	*this = v.(Univalues)
+	univalues, err := this.TryDecode(data)
+	if err != nil {
+		return err
+	}
+	*this = univalues
+	return nil
+}
+
+// The univalues are marshaled one by one, the decoding needs the concrete type.
+func (this *Univalues) UnmarshalJSON(data []byte) error {
+	univalues, err := univalue.UnivaluesFromJSON(data)
+	if err != nil {
+		return err
+	}
+	*this = univalues
 	return nil
 }
 
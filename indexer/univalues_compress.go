@@ -0,0 +1,145 @@
+package indexer
+
+import (
+	"errors"
+	"math"
+
+	"github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/common-lib/common"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	univalue "github.com/arcology-network/concurrenturl/univalue"
+)
+
+const (
+	COMPRESSED_FLAG = uint32(1) << 31 // Set in the count field of a compressed batch
+	NO_ACCOUNT      = math.MaxUint32  // The path doesn't belong to an account
+)
+
+// IsCompressed checks the header flag, a plain batch never has the highest bit of the count set.
+func (this Univalues) IsCompressed(bytes []byte) bool {
+	_, body, err := this.Version(bytes)
+	return err == nil && this.isCompressed(body)
+}
+
+func (Univalues) isCompressed(body []byte) bool {
+	return len(body) >= codec.UINT32_LEN && uint32(codec.Uint32(0).Decode(body).(codec.Uint32))&COMPRESSED_FLAG != 0
+}
+
+// EncodeCompressed encodes the univalues with the paths stripped out. The accounts are put
+// into a dictionary and the remaining part of each path is stored as the delta from the previous one.
+//
+//	[version header][count|COMPRESSED_FLAG][accounts][(account index, shared length)...][suffixes][plain univalues]
+func (this Univalues) EncodeCompressed() []byte {
+	accounts, dict := []string{}, map[string]uint32{}
+	refs := make([]uint32, 2*len(this))
+	suffixes := make([]string, len(this))
+	stripped := make([]interfaces.Univalue, len(this))
+
+	empty, prev := "", ""
+	for i, v := range this {
+		acct, rest := this.splitPath(*v.GetPath())
+		refs[2*i] = NO_ACCOUNT
+		if len(acct) > 0 {
+			idx, ok := dict[acct]
+			if !ok {
+				idx = uint32(len(accounts))
+				dict[acct] = idx
+				accounts = append(accounts, acct)
+			}
+			refs[2*i] = idx
+		}
+
+		shared := this.sharedLength(prev, rest)
+		refs[2*i+1] = uint32(shared)
+		suffixes[i] = rest[shared:]
+		prev = rest
+
+		meta := *v.GetUnimeta().(*univalue.Unimeta)
+		meta.SetPath(&empty)
+		stripped[i] = (&univalue.Univalue{}).New(&meta, v.Value(), []byte{}).(*univalue.Univalue)
+	}
+
+	body := codec.Byteset{
+		codec.Strings(accounts).Encode(),
+		codec.Uint32s(refs).Encode(),
+		codec.Strings(suffixes).Encode(),
+		Univalues(stripped).encode(0),
+	}.Encode()
+
+	buffer := make([]byte, VERSION_HEADER_LEN+codec.UINT32_LEN+len(body))
+	this.FillVersionHeader(buffer, CODEC_VERSION)
+	codec.Uint32(uint32(len(this)) | COMPRESSED_FLAG).EncodeToBuffer(buffer[VERSION_HEADER_LEN:])
+	copy(buffer[VERSION_HEADER_LEN+codec.UINT32_LEN:], body)
+	return buffer
+}
+
+// decompress extracts the plain univalue batch and rebuilds the full paths.
+func (Univalues) decompress(bytes []byte) ([]byte, []string, error) {
+	if len(bytes) < codec.UINT32_LEN || ccurlcommon.CheckByteset(bytes[codec.UINT32_LEN:]) != nil {
+		return nil, nil, errors.New("Error: Malformed compressed univalues!")
+	}
+
+	count := int(uint32(codec.Uint32(0).Decode(bytes).(codec.Uint32)) &^ COMPRESSED_FLAG)
+	fields := codec.Byteset{}.Decode(bytes[codec.UINT32_LEN:]).(codec.Byteset)
+	if len(fields) != 4 || ccurlcommon.CheckByteset(fields[0]) != nil || len(fields[1])%codec.UINT32_LEN != 0 || ccurlcommon.CheckByteset(fields[2]) != nil {
+		return nil, nil, errors.New("Error: Malformed compressed univalues!")
+	}
+
+	accounts := codec.Strings{}.Decode(fields[0]).(codec.Strings)
+	refs := codec.Uint32s{}.Decode(fields[1]).(codec.Uint32s)
+	suffixes := codec.Strings{}.Decode(fields[2]).(codec.Strings)
+	if len(refs) != 2*count || len(suffixes) != count {
+		return nil, nil, errors.New("Error: Malformed compressed univalues!")
+	}
+
+	paths := make([]string, count)
+	prev := ""
+	for i := 0; i < count; i++ {
+		if int(refs[2*i+1]) > len(prev) {
+			return nil, nil, errors.New("Error: Malformed compressed univalues!")
+		}
+		rest := prev[:refs[2*i+1]] + suffixes[i]
+		prev = rest
+
+		if refs[2*i] == NO_ACCOUNT {
+			paths[i] = rest
+			continue
+		}
+
+		if int(refs[2*i]) >= len(accounts) {
+			return nil, nil, errors.New("Error: Account index out of range!")
+		}
+		paths[i] = ccurlcommon.ETH10_ACCOUNT_PREFIX + accounts[refs[2*i]] + rest
+	}
+	return fields[3], paths, nil
+}
+
+func (this Univalues) setPaths(paths []string) (Univalues, error) {
+	if len(this) != len(paths) {
+		return nil, errors.New("Error: Path count mismatch!")
+	}
+
+	for i := range this {
+		this[i].GetUnimeta().(*univalue.Unimeta).SetPath(&paths[i])
+	}
+	return this, nil
+}
+
+// splitPath returns the account and the rest of the path, the account is empty for non-account paths.
+func (Univalues) splitPath(path string) (string, string) {
+	if len(path) < ccurlcommon.ETH10_ACCOUNT_FULL_LENGTH || path[:ccurlcommon.ETH10_ACCOUNT_PREFIX_LENGTH] != ccurlcommon.ETH10_ACCOUNT_PREFIX {
+		return "", path
+	}
+	return path[ccurlcommon.ETH10_ACCOUNT_PREFIX_LENGTH:ccurlcommon.ETH10_ACCOUNT_FULL_LENGTH], path[ccurlcommon.ETH10_ACCOUNT_FULL_LENGTH:]
+}
+
+func (Univalues) sharedLength(lhv, rhv string) int {
+	length := common.Min(len(lhv), len(rhv))
+	for i := 0; i < length; i++ {
+		if lhv[i] != rhv[i] {
+			return i
+		}
+	}
+	return length
+}
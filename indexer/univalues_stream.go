@@ -0,0 +1,147 @@
+package indexer
+
+import (
+	"errors"
+	"io"
+
+	"github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	univalue "github.com/arcology-network/concurrenturl/univalue"
+)
+
+// The default limit on the size of a single univalue in a stream, a corrupted length prefix
+// can't make the reader allocate more than that.
+const MAX_STREAM_RECORD_SIZE = 64 << 20
+
+var ErrRecordTooLarge = errors.New("Error: The univalue exceeds the max record size")
+
+// UnivaluesWriter writes univalues to a stream one at a time, each one prefixed with its length,
+// so the whole batch never needs to be in memory.
+//
+//	[version header][length][univalue][length][univalue]...
+type UnivaluesWriter struct {
+	writer  io.Writer
+	started bool
+	count   int
+	buffer  []byte
+}
+
+func NewUnivaluesWriter(writer io.Writer) *UnivaluesWriter {
+	return &UnivaluesWriter{writer: writer}
+}
+
+func (this *UnivaluesWriter) Count() int { return this.count }
+
+func (this *UnivaluesWriter) Write(v interfaces.Univalue) error {
+	if v == nil {
+		return errors.New("Error: Nil univalue!")
+	}
+
+	if v.Size() > MAX_STREAM_RECORD_SIZE { // The readers wouldn't accept it
+		return ErrRecordTooLarge
+	}
+
+	if !this.started {
+		header := make([]byte, VERSION_HEADER_LEN)
+		Univalues{}.FillVersionHeader(header, CODEC_VERSION)
+		if _, err := this.writer.Write(header); err != nil {
+			return err
+		}
+		this.started = true
+	}
+
+	size := int(v.Size()) + codec.UINT32_LEN
+	if cap(this.buffer) < size {
+		this.buffer = make([]byte, size)
+	}
+	this.buffer = this.buffer[:size]
+
+	codec.Uint32(v.Size()).EncodeToBuffer(this.buffer)
+	v.EncodeToBuffer(this.buffer[codec.UINT32_LEN:])
+	if _, err := this.writer.Write(this.buffer); err != nil {
+		return err
+	}
+	this.count++
+	return nil
+}
+
+func (this *UnivaluesWriter) WriteAll(univalues Univalues) error {
+	for _, v := range univalues {
+		if err := this.Write(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnivaluesReader reads the univalues written by UnivaluesWriter back incrementally.
+type UnivaluesReader struct {
+	reader  io.Reader
+	started bool
+	prefix  [codec.UINT32_LEN]byte
+	maxSize uint32
+}
+
+func NewUnivaluesReader(reader io.Reader) *UnivaluesReader {
+	return &UnivaluesReader{reader: reader, maxSize: MAX_STREAM_RECORD_SIZE}
+}
+
+// SetMaxRecordSize changes the limit on the size of a single univalue.
+func (this *UnivaluesReader) SetMaxRecordSize(size uint32) *UnivaluesReader {
+	this.maxSize = size
+	return this
+}
+
+// Read returns the next univalue, or io.EOF when the stream has ended cleanly.
+func (this *UnivaluesReader) Read() (interfaces.Univalue, error) {
+	if !this.started {
+		header := make([]byte, VERSION_HEADER_LEN)
+		if _, err := io.ReadFull(this.reader, header); err != nil {
+			return nil, err
+		}
+
+		if version, _, err := (Univalues{}).Version(header); err != nil {
+			return nil, err
+		} else if version == CODEC_V0 {
+			return nil, errors.New("Error: Missing stream header!")
+		}
+		this.started = true
+	}
+
+	if _, err := io.ReadFull(this.reader, this.prefix[:]); err != nil {
+		return nil, err // io.EOF only if the stream ends between two univalues
+	}
+
+	size := uint32(codec.Uint32(0).Decode(this.prefix[:]).(codec.Uint32))
+	if size > this.maxSize {
+		return nil, ErrRecordTooLarge
+	}
+
+	// The decoded univalue references the buffer, so it can't be reused.
+	buffer := make([]byte, size)
+	if _, err := io.ReadFull(this.reader, buffer); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if err := (&univalue.Univalue{}).CheckEncoding(buffer); err != nil {
+		return nil, err
+	}
+	return new(univalue.Univalue).DecodeTo(buffer), nil
+}
+
+// ReadChunk reads up to n univalues, it returns io.EOF with an empty chunk at the end of the stream.
+func (this *UnivaluesReader) ReadChunk(n int) (Univalues, error) {
+	chunk := make([]interfaces.Univalue, 0, n)
+	for len(chunk) < n {
+		v, err := this.Read()
+		if err == io.EOF && len(chunk) > 0 {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		chunk = append(chunk, v)
+	}
+	return chunk, nil
+}
@@ -1,8 +1,10 @@
 package indexer
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/arcology-network/common-lib/codec"
 	"github.com/arcology-network/common-lib/common"
 	"github.com/arcology-network/common-lib/datacompression"
 	commutative "github.com/arcology-network/concurrenturl/commutative"
@@ -101,3 +103,187 @@ func TestUnivaluesCodeMeta(t *testing.T) {
 		t.Error("Error")
 	}
 }
+
+func TestUnivaluesPooledDecode(t *testing.T) {
+	alice := datacompression.RandomAccount()
+
+	in := []interfaces.Univalue{
+		univalue.NewUnivalue(1, "blcc://eth1.0/account/"+alice+"/storage/ctrn-0/u64-000", 3, 4, 0, commutative.NewBoundedUint64(0, 100), nil),
+		univalue.NewUnivalue(1, "blcc://eth1.0/account/"+alice+"/storage/ctrn-0/u256-000", 3, 4, 0, commutative.NewBoundedU256(uint256.NewInt(0), uint256.NewInt(100)), nil),
+	}
+	buffer := Univalues(in).Encode()
+
+	pool := NewUnivaluePool("test")
+	out, _ := Univalues{}.DecodeV2(buffer, pool.Get, pool.Put)
+	if !Univalues(in).Equal(out) {
+		t.Error("Error: Mismatch")
+	}
+
+	if inUse, free := pool.Stats(); inUse != 2 || free != 0 {
+		t.Error("Error: Wrong stats", inUse, free)
+	}
+
+	out[0].(*univalue.Univalue).Reclaim() // Return one early
+	pool.Put(out[0])                      // Putting twice has no effect
+	if inUse, free := pool.Stats(); inUse != 1 || free != 1 {
+		t.Error("Error: Wrong stats", inUse, free)
+	}
+
+	pool.Reclaim()
+	if inUse, free := pool.Stats(); inUse != 0 || free != 2 || out[1].GetPath() != nil {
+		t.Error("Error: Failed to reclaim", inUse, free)
+	}
+
+	// Reuse the reclaimed objects
+	reused, _ := Univalues{}.DecodeV2(buffer, pool.Get, pool.Put)
+	if !Univalues(in).Equal(reused) || (reused[0] != out[1] && reused[1] != out[1]) {
+		t.Error("Error: The reclaimed univalues should be reused")
+	}
+
+	if inUse, free := pool.Stats(); inUse != 2 || free != 0 {
+		t.Error("Error: Wrong stats", inUse, free)
+	}
+}
+
+func TestUnivaluesCompressedCodec(t *testing.T) {
+	alice := datacompression.RandomAccount()
+	bob := datacompression.RandomAccount()
+
+	in := []interfaces.Univalue{
+		univalue.NewUnivalue(1, "blcc://eth1.0/account/"+alice+"/storage/ctrn-0/u64-000", 3, 4, 0, commutative.NewBoundedUint64(0, 100), nil),
+		univalue.NewUnivalue(1, "blcc://eth1.0/account/"+alice+"/storage/ctrn-0/u256-000", 3, 4, 0, commutative.NewBoundedU256(uint256.NewInt(0), uint256.NewInt(100)), nil),
+		univalue.NewUnivalue(2, "blcc://eth1.0/account/"+bob+"/storage/ctrn-0/u64-000", 1, 1, 0, commutative.NewBoundedUint64(0, 100), nil),
+		univalue.NewUnivalue(2, "blcc://eth1.0/account/", 1, 0, 0, nil, nil),
+		univalue.NewUnivalue(3, "blcc://eth1.0/account/"+alice+"/", 1, 0, 0, nil, nil),
+	}
+
+	plain := Univalues(in).Encode()
+	compressed := Univalues(in).EncodeCompressed()
+	if (Univalues{}).IsCompressed(plain) || !(Univalues{}).IsCompressed(compressed) {
+		t.Error("Error: Wrong header flag")
+	}
+
+	if len(compressed) >= len(plain) {
+		t.Error("Error: The compressed batch should be smaller", len(compressed), len(plain))
+	}
+
+	// Both formats decode through the same entry
+	for _, buffer := range [][]byte{plain, compressed} {
+		out := Univalues{}.Decode(buffer).(Univalues)
+		if len(out) != len(in) || !Univalues(in).Equal(out) {
+			t.Error("Error: Mismatch")
+		}
+
+		for i := range in {
+			if *in[i].GetPath() != *out[i].GetPath() {
+				t.Error("Error: Path mismatch", *in[i].GetPath(), *out[i].GetPath())
+			}
+		}
+	}
+
+	pool := NewUnivaluePool("test")
+	out, _ := Univalues{}.DecodeV2(compressed, pool.Get, pool.Put)
+	if !Univalues(in).Equal(out) || *out[2].GetPath() != *in[2].GetPath() {
+		t.Error("Error: Mismatch")
+	}
+
+	if out := (Univalues{}).Decode((Univalues{}).EncodeCompressed()).(Univalues); len(out) != 0 {
+		t.Error("Error: Should be empty")
+	}
+
+	// One path for two univalues
+	body := codec.Byteset{
+		codec.Strings{}.Encode(),
+		codec.Uint32s{NO_ACCOUNT, 0}.Encode(),
+		codec.Strings{"blcc://eth1.0/account/"}.Encode(),
+		Univalues(in[:2]).encode(0),
+	}.Encode()
+	mismatched := make([]byte, VERSION_HEADER_LEN+codec.UINT32_LEN+len(body))
+	(Univalues{}).FillVersionHeader(mismatched, CODEC_VERSION)
+	codec.Uint32(1 | COMPRESSED_FLAG).EncodeToBuffer(mismatched[VERSION_HEADER_LEN:])
+	copy(mismatched[VERSION_HEADER_LEN+codec.UINT32_LEN:], body)
+
+	truncated := common.Clone(compressed[:VERSION_HEADER_LEN+codec.UINT32_LEN+6])
+	for _, buffer := range [][]byte{mismatched, truncated} {
+		if _, err := (Univalues{}).TryDecode(buffer); err == nil {
+			t.Error("Error: Should reject a malformed compressed batch")
+		}
+
+		if _, err := (Univalues{}).DecodeV2(buffer, pool.Get, pool.Put); err == nil {
+			t.Error("Error: Should reject a malformed compressed batch")
+		}
+	}
+}
+
+func TestUnivaluesCodecVersion(t *testing.T) {
+	alice := datacompression.RandomAccount()
+	in := []interfaces.Univalue{
+		univalue.NewUnivalue(1, "blcc://eth1.0/account/"+alice+"/storage/ctrn-0/u64-000", 3, 4, 0, commutative.NewBoundedUint64(0, 100), nil),
+	}
+
+	buffer := Univalues(in).Encode()
+	if version, _, err := (Univalues{}).Version(buffer); version != CODEC_VERSION || err != nil || len(buffer) != Univalues(in).Size() {
+		t.Error("Error: Wrong version header", version, err)
+	}
+
+	// Batches from before the versioning still decode
+	legacy := Univalues(in).encode(0)
+	if out, err := (Univalues{}).TryDecode(legacy); err != nil || !Univalues(in).Equal(out) {
+		t.Error("Error: Failed to decode a legacy batch", err)
+	}
+
+	(Univalues{}).FillVersionHeader(buffer, CODEC_VERSION+1)
+	if out, err := (Univalues{}).TryDecode(buffer); out != nil || !errors.Is(err, ErrUnknownCodecVersion) {
+		t.Error("Error: Should reject unknown versions", err)
+	}
+
+	// None of the entries panics
+	pool := NewUnivaluePool("test")
+	if out, err := (Univalues{}).DecodeV2(buffer, pool.Get, pool.Put); out != nil || !errors.Is(err, ErrUnknownCodecVersion) {
+		t.Error("Error: Should reject unknown versions", err)
+	}
+
+	if err, ok := (Univalues{}).Decode(buffer).(error); !ok || !errors.Is(err, ErrUnknownCodecVersion) {
+		t.Error("Error: Should return the error", err)
+	}
+
+	var decoded Univalues
+	if err := decoded.GobDecode(buffer); !errors.Is(err, ErrUnknownCodecVersion) {
+		t.Error("Error: Should reject unknown versions", err)
+	}
+
+	// A garbled body behind a valid header
+	garbled := Univalues(in).Encode()
+	codec.Uint32(1 << 30).EncodeToBuffer(garbled[VERSION_HEADER_LEN:])
+	if _, err := (Univalues{}).TryDecode(garbled); err == nil {
+		t.Error("Error: Should reject a malformed batch")
+	}
+
+	if _, err := (Univalues{}).DecodeV2(garbled, pool.Get, pool.Put); err == nil {
+		t.Error("Error: Should reject a malformed batch")
+	}
+
+	// Malformed univalues in a well formed batch
+	meta := in[0].GetUnimeta().(*univalue.Unimeta).Encode()
+	elements := [][]byte{
+		{1, 0, 0, 0}, // A byteset too short
+		codec.Byteset{meta}.Encode(),
+		codec.Byteset{codec.Byteset{{1}, {2}}.Encode(), {}}.Encode(),                                                     // Too few meta fields
+		codec.Byteset{append(common.Clone(meta[:len(meta)-8]), 1, 2), in[0].Value().(interfaces.Type).Encode()}.Encode(), // A short field
+	}
+
+	for i, element := range elements {
+		batch := codec.Byteset{in[0].(*univalue.Univalue).Encode(), element}.Encode()
+		buffer := make([]byte, VERSION_HEADER_LEN+len(batch))
+		(Univalues{}).FillVersionHeader(buffer, CODEC_VERSION)
+		copy(buffer[VERSION_HEADER_LEN:], batch)
+
+		if _, err := (Univalues{}).TryDecode(buffer); err == nil {
+			t.Error("Error: Should reject a malformed univalue", i)
+		}
+
+		if _, err := (Univalues{}).DecodeV2(buffer, pool.Get, pool.Put); err == nil {
+			t.Error("Error: Should reject a malformed univalue", i)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/arcology-network/common-lib/codec"
+)
+
+const (
+	CODEC_MAGIC = uint32(0xCC0DEC55) // Marks a versioned univalue batch
+
+	CODEC_V0      = uint32(0) // The original unversioned layout, starting with the count directly
+	CODEC_V1      = uint32(1) // The V0 layout behind the magic and version header
+	CODEC_VERSION = CODEC_V1
+
+	VERSION_HEADER_LEN = 2 * codec.UINT32_LEN
+)
+
+var ErrUnknownCodecVersion = errors.New("Error: Unknown univalues codec version")
+
+func (Univalues) FillVersionHeader(buffer []byte, version uint32) {
+	codec.Uint32(CODEC_MAGIC).EncodeToBuffer(buffer)
+	codec.Uint32(version).EncodeToBuffer(buffer[codec.UINT32_LEN:])
+}
+
+// Version returns the codec version of the batch and the body after the header.
+// Batches without the magic number are from before the versioning and decoded as V0.
+func (Univalues) Version(bytes []byte) (uint32, []byte, error) {
+	if len(bytes) < VERSION_HEADER_LEN || uint32(codec.Uint32(0).Decode(bytes).(codec.Uint32)) != CODEC_MAGIC {
+		return CODEC_V0, bytes, nil
+	}
+
+	version := uint32(codec.Uint32(0).Decode(bytes[codec.UINT32_LEN:]).(codec.Uint32))
+	switch version {
+	case CODEC_V1:
+		return version, bytes[VERSION_HEADER_LEN:], nil
+	}
+	return version, nil, fmt.Errorf("%w: %d, the latest supported is %d", ErrUnknownCodecVersion, version, CODEC_VERSION)
+}
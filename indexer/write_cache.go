@@ -13,6 +13,7 @@ import (
 	concurrenturlcommon "github.com/arcology-network/concurrenturl/common"
 	"github.com/arcology-network/concurrenturl/commutative"
 	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
 	univalue "github.com/arcology-network/concurrenturl/univalue"
 )
 
@@ -81,15 +82,48 @@ func (this *WriteCache) Retrive(path string, T any) (interface{}, error) {
 	return typedv.(interfaces.Type).New(rawv, nil, nil, typedv.(interfaces.Type).Min(), typedv.(interfaces.Type).Max()), nil // Return in a new univalue
 }
 
+// Read a field of a struct, the access is recorded at the field level
+func (this *WriteCache) ReadField(tx uint32, path string, idx uint32, T any) (interface{}, interface{}, error) {
+	univ := this.GetOrInit(tx, path, T)
+	v, err := univ.(*univalue.Univalue).GetField(tx, idx)
+	return v, univ, err
+}
+
+// Update a field of an existing struct, the other fields won't be affected
+func (this *WriteCache) WriteField(tx uint32, path string, idx uint32, value interface{}, T any) error {
+	return this.GetOrInit(tx, path, T).(*univalue.Univalue).SetField(tx, idx, value)
+}
+
+// Read the length of a path only
+func (this *WriteCache) ReadLength(tx uint32, path string) (int, interface{}) {
+	univ := this.GetOrInit(tx, path, new(commutative.Path))
+	return univ.(*univalue.Univalue).Length(tx), univ
+}
+
+// Check if a key exists under a path only
+func (this *WriteCache) ReadKeyExists(tx uint32, path string, key string) (bool, interface{}) {
+	univ := this.GetOrInit(tx, path, new(commutative.Path))
+	return univ.(*univalue.Univalue).Has(tx, key), univ
+}
+
 func (this *WriteCache) Do(tx uint32, path string, doer interface{}, T any) interface{} {
 	univalue := this.GetOrInit(tx, path, T)
 	return univalue.Do(tx, path, doer)
 }
 
 func (this *WriteCache) Write(tx uint32, path string, value interface{}) error {
+	if value != nil && ccurlcommon.PathDepth(path) > ccurlcommon.MAX_DEPTH {
+		return errors.New("Error: Exceeded the max depth: " + path)
+	}
+
 	parentPath := common.GetParentPath(path)
 	if this.IfExists(parentPath) || tx == ccurlcommon.SYSTEM { // The parent path exists or to inject the path directly
-		univalue := this.GetOrInit(tx, path, value) // Get a univalue wrapper
+		T := value
+		if value == nil { // A deletion, the committed value only needs to be loaded as a placeholder, except for the paths
+			T = common.IfThen(common.IsPath(path), interface{}(new(commutative.Path)), interface{}(new(noncommutative.Bytes)))
+		}
+
+		univalue := this.GetOrInit(tx, path, T) // Get a univalue wrapper
 
 		err := univalue.Set(tx, path, value, this)
 		if err == nil {
@@ -128,7 +162,7 @@ func (this *WriteCache) AddTransitions(transitions []interfaces.Univalue) {
 		return common.IsPath(*v.GetPath())
 	})
 
-	// Not necessary at the moment, but good for the future if multiple level containers are available
+	// The parent paths must be created before their children in the nested containers
 	newPathCreations = Univalues(Sorter(newPathCreations))
 	common.Foreach(newPathCreations, func(v *interfaces.Univalue, _ int) {
 		(*v).Merge(this) // Write back to the parent writecache
@@ -60,6 +60,17 @@ type Type interface { // value type
 	Print()
 }
 
+type FieldAccessor interface { // value types supporting field level accesses
+	NumFields() uint32
+	GetField(uint32) (interface{}, error)
+	SetField(uint32, interface{}) error
+}
+
+type Container interface { // value types having sub keys
+	Length() int
+	Has(string) bool
+}
+
 type Univalue interface { // value type
 	TypeID() uint8
 	Reads() uint32
@@ -74,6 +85,16 @@ type Univalue interface { // value type
 	IncrementWrites(uint32)
 	IncrementDeltaWrites(uint32)
 
+	IsFieldLevel() bool
+	FieldReads() []uint32
+	FieldWrites() []uint32
+
+	IsPartialRead() bool
+	LengthReads() uint32
+	KeyReads() []string
+	KeyWrites() []string
+	LengthDelta() int32
+
 	// IsHotLoaded() bool
 	Set(uint32, string, interface{}, interface{}) error
 	Get(uint32, string, interface{}) interface{}
@@ -146,4 +167,11 @@ type Datastore interface {
 	// CacheRetrive(key string, valueTransformer func(interface{}) interface{}) (interface{}, error)
 }
 
+// The index of the values written with a TTL by their expiry heights. It is kept by the datastores
+// outside of the state, so it doesn't affect the state root.
+type ExpiryIndex interface {
+	ExpiringAt(uint64) map[uint64][]string              // The keys expiring at or below a height
+	UpdateExpiring([]uint64, map[uint64][]string) error // Remove the entries at some heights, then add the keys to the others
+}
+
 type Hasher func(Type) []byte
@@ -1,7 +1,9 @@
 package noncommutative
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 
 	codec "github.com/arcology-network/common-lib/codec"
 	"github.com/arcology-network/evm/rlp"
@@ -58,3 +60,12 @@ func (this *Bigint) Print() {
 	fmt.Println(*this)
 	fmt.Println()
 }
+
+// A decimal number, so the big values stay precise.
+func (this *Bigint) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*big.Int)(this))
+}
+
+func (this *Bigint) UnmarshalJSON(buffer []byte) error {
+	return (*big.Int)(this).UnmarshalJSON(buffer)
+}
@@ -2,9 +2,11 @@ package noncommutative
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 
 	codec "github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/evm/common/hexutil"
 )
 
 func (this *Bytes) HeaderSize() uint32 {
@@ -58,3 +60,16 @@ func (this *Bytes) Print() {
 	fmt.Println(*this)
 	fmt.Println()
 }
+
+func (this *Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hexutil.Bytes(this.value))
+}
+
+func (this *Bytes) UnmarshalJSON(buffer []byte) error {
+	var v hexutil.Bytes
+	if err := json.Unmarshal(buffer, &v); err != nil {
+		return err
+	}
+	this.placeholder, this.value = true, codec.Bytes(v)
+	return nil
+}
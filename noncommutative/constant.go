@@ -1,8 +1,10 @@
 package noncommutative
 
 const (
-	INT64  uint8 = 104
-	STRING uint8 = 105
-	BIGINT uint8 = 106
-	BYTES  uint8 = 107
+	INT64    uint8 = 104
+	STRING   uint8 = 105
+	BIGINT   uint8 = 106
+	BYTES    uint8 = 107
+	STRUCT   uint8 = 108
+	EXPIRING uint8 = 109
 )
@@ -0,0 +1,147 @@
+package noncommutative
+
+import (
+	"errors"
+
+	"github.com/arcology-network/common-lib/common"
+	"github.com/arcology-network/concurrenturl/interfaces"
+)
+
+// The wrapped values are decoded by their type IDs, the decoders are set by the type registry,
+// which can't be imported here directly.
+var (
+	decodeByID        func(uint8, []byte) interface{}
+	storageDecodeByID func(uint8, []byte) interface{}
+	newByID           func(uint8) interfaces.Type
+)
+
+func SetTypeDecoders(decoder, storageDecoder func(uint8, []byte) interface{}) {
+	decodeByID, storageDecodeByID = decoder, storageDecoder
+}
+
+// Create the wrapped values by their type IDs for the JSON decoding.
+func SetTypeFactory(factory func(uint8) interfaces.Type) { newByID = factory }
+
+// Expiring wraps a noncommutative value with the block height at which it expires.
+// An expired value is treated as absent and will be removed in a later commit.
+type Expiring struct {
+	value  interfaces.Type
+	expiry uint64
+}
+
+func NewExpiring(value interfaces.Type, expiry uint64) interfaces.Type {
+	return &Expiring{
+		value:  value,
+		expiry: expiry,
+	}
+}
+
+func (this *Expiring) Expiry() uint64               { return this.expiry }
+func (this *Expiring) IsExpired(height uint64) bool { return height >= this.expiry }
+func (this *Expiring) Unwrap() interfaces.Type      { return this.value }
+
+func (this *Expiring) MemSize() uint32 {
+	return 8 + common.IfThenDo1st(this.value != nil, func() uint32 { return this.value.MemSize() }, 0)
+}
+
+func (this *Expiring) IsSelf(key interface{}) bool { return true }
+func (this *Expiring) TypeID() uint8               { return EXPIRING }
+
+func (this *Expiring) CopyTo(v interface{}) (interface{}, uint32, uint32, uint32) {
+	return v, 0, 1, 0
+}
+
+func (this *Expiring) Clone() interface{} {
+	return &Expiring{
+		value:  common.IfThenDo1st(this.value != nil, func() interfaces.Type { return this.value.Clone().(interfaces.Type) }, nil),
+		expiry: this.expiry,
+	}
+}
+
+func (this *Expiring) Equal(other interface{}) bool {
+	rhv := other.(*Expiring)
+	if this.expiry != rhv.expiry || (this.value == nil) != (rhv.value == nil) {
+		return false
+	}
+	return this.value == nil || this.value.Equal(rhv.value)
+}
+
+func (this *Expiring) IsNumeric() bool     { return false }
+func (this *Expiring) IsCommutative() bool { return false }
+func (this *Expiring) IsBounded() bool     { return false }
+
+func (this *Expiring) Value() interface{} { return this }
+func (this *Expiring) Delta() interface{} { return this }
+func (this *Expiring) DeltaSign() bool    { return true } // delta sign
+func (this *Expiring) Min() interface{}   { return nil }
+func (this *Expiring) Max() interface{}   { return nil }
+
+func (this *Expiring) CloneDelta() interface{} { return this.Clone() }
+func (this *Expiring) SetValue(v interface{})  { this.SetDelta(v) }
+
+func (this *Expiring) IsDeltaApplied() bool       { return true }
+func (this *Expiring) ResetDelta()                {}
+func (this *Expiring) SetDelta(v interface{})     { *this = *(v.(*Expiring).Clone().(*Expiring)) }
+func (this *Expiring) SetDeltaSign(v interface{}) {}
+func (this *Expiring) SetMin(v interface{})       {}
+func (this *Expiring) SetMax(v interface{})       {}
+
+// Get the wrapped value
+func (this *Expiring) Get() (interface{}, uint32, uint32) {
+	if this.value == nil {
+		return nil, 1, 0
+	}
+
+	v, _, _ := this.value.Get()
+	return v, 1, 0
+}
+
+func (this *Expiring) New(_, delta, _, _, _ interface{}) interface{} {
+	return common.IfThenDo1st(delta != nil && delta.(*Expiring) != nil, func() interface{} { return delta.(*Expiring).Clone() }, interface{}(this))
+}
+
+// An expiring value can only be replaced by another one before it expires, which also refreshes the expiry height.
+// The expired ones are deleted first by the writers, so they can be replaced by any type.
+func (this *Expiring) Set(value interface{}, _ interface{}) (interface{}, uint32, uint32, uint32, error) {
+	if value == nil || this == value { // Deletion or self copy
+		return this, 0, 1, 0, nil
+	}
+
+	if _, ok := value.(*Expiring); !ok {
+		return this, 0, 1, 0, errors.New("Error: An expiring value can only be replaced by another expiring value")
+	}
+
+	this.SetDelta(value)
+	return this, 0, 1, 0, nil
+}
+
+func (this *Expiring) ApplyDelta(v interface{}) (interfaces.Type, int, error) {
+	vec := v.([]interfaces.Univalue)
+	for i := 0; i < len(vec); i++ {
+		v := vec[i].Value()
+		if this == nil && v != nil { // New value
+			this = v.(*Expiring)
+		}
+
+		if this == nil && v == nil {
+			this = nil
+		}
+
+		if _, ok := v.(*Expiring); this != nil && v != nil && !ok { // Replaced by another type after expiring
+			return v.(interfaces.Type).ApplyDelta(vec[i+1:])
+		}
+
+		if this != nil && v != nil {
+			this.Set(v.(*Expiring), nil)
+		}
+
+		if this != nil && v == nil {
+			this = nil
+		}
+	}
+
+	if this == nil {
+		return nil, 0, nil
+	}
+	return this, len(vec), nil
+}
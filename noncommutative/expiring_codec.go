@@ -0,0 +1,166 @@
+package noncommutative
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	codec "github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/common-lib/common"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	"github.com/arcology-network/evm/rlp"
+)
+
+// The storage encoding of an expiring value starts with the marker. No RLP item starts with it, since
+// the item would be longer than 2^56 bytes, so the other values sharing the same key space are never
+// mistaken for an expiring one.
+const EXPIRING_STORAGE_MARKER uint8 = 0xff
+
+func IsExpiringEncoding(buffer []byte) bool {
+	return len(buffer) > 0 && buffer[0] == EXPIRING_STORAGE_MARKER
+}
+
+type expiringRlp struct {
+	Expiry uint64
+	ID     uint8
+	Value  []byte
+}
+
+func (this *Expiring) innerID() uint8 {
+	return common.IfThenDo1st(this.value != nil, func() uint8 { return this.value.TypeID() }, 0)
+}
+
+func (this *Expiring) fieldSizes() []uint32 {
+	return []uint32{
+		codec.Uint64(this.expiry).Size(),
+		codec.Uint8(this.innerID()).Size(),
+		common.IfThenDo1st(this.value != nil, func() uint32 { return this.value.Size() }, 0),
+	}
+}
+
+func (this *Expiring) HeaderSize() uint32 {
+	return uint32(len(this.fieldSizes())+1) * codec.UINT32_LEN // number of fields + 1
+}
+
+func (this *Expiring) Size() uint32 {
+	return this.HeaderSize() + common.Sum(this.fieldSizes())
+}
+
+func (this *Expiring) Encode() []byte {
+	buffer := make([]byte, this.Size())
+	offset := codec.Encoder{}.FillHeader(buffer, this.fieldSizes())
+	this.EncodeToBuffer(buffer[offset:])
+	return buffer
+}
+
+func (this *Expiring) EncodeToBuffer(buffer []byte) int {
+	offset := codec.Uint64(this.expiry).EncodeToBuffer(buffer)
+	offset += codec.Uint8(this.innerID()).EncodeToBuffer(buffer[offset:])
+	offset += common.IfThenDo1st(this.value != nil, func() int { return copy(buffer[offset:], this.value.Encode()) }, 0)
+	return offset
+}
+
+func (this *Expiring) Decode(buffer []byte) interface{} {
+	if len(buffer) == 0 {
+		return this
+	}
+
+	fields := codec.Byteset{}.Decode(buffer).(codec.Byteset)
+	return &Expiring{
+		value:  this.decodeValue(decodeByID, uint8(codec.Uint8(0).Decode(fields[1]).(codec.Uint8)), fields[2]),
+		expiry: uint64(codec.Uint64(0).Decode(fields[0]).(codec.Uint64)),
+	}
+}
+
+func (this *Expiring) decodeValue(decoder func(uint8, []byte) interface{}, id uint8, buffer []byte) interfaces.Type {
+	if decoder == nil || id == 0 {
+		return nil
+	}
+
+	if v := decoder(id, buffer); v != nil {
+		return v.(interfaces.Type)
+	}
+	return nil
+}
+
+func (this *Expiring) StorageEncode() []byte {
+	buffer, err := rlp.EncodeToBytes(&expiringRlp{
+		Expiry: this.expiry,
+		ID:     this.innerID(),
+		Value:  common.IfThenDo1st(this.value != nil, func() []byte { return this.value.StorageEncode() }, []byte{}),
+	})
+
+	if err != nil {
+		panic("Failed to encode the expiring value")
+	}
+	return append([]byte{EXPIRING_STORAGE_MARKER}, buffer...)
+}
+
+// Return nil if the buffer doesn't contain an expiring value.
+func (this *Expiring) StorageDecode(buffer []byte) interface{} {
+	if !IsExpiringEncoding(buffer) {
+		return nil
+	}
+
+	var decoded expiringRlp
+	if err := rlp.DecodeBytes(buffer[1:], &decoded); err != nil {
+		return nil
+	}
+
+	return &Expiring{
+		value:  this.decodeValue(storageDecodeByID, decoded.ID, decoded.Value),
+		expiry: decoded.Expiry,
+	}
+}
+
+func (this *Expiring) Reset() {}
+
+func (this *Expiring) Hash(hasher func([]byte) []byte) []byte {
+	return hasher(this.Encode())
+}
+
+func (this *Expiring) Print() {
+	fmt.Println("Expiry: ", this.expiry, "Value: ", this.value)
+	fmt.Println()
+}
+
+type expiringJSON struct {
+	Expiry uint64          `json:"expiry"`
+	Type   uint8           `json:"type"`
+	Value  json.RawMessage `json:"value"`
+}
+
+func (this *Expiring) MarshalJSON() ([]byte, error) {
+	if this.value == nil {
+		return json.Marshal(expiringJSON{Expiry: this.expiry, Value: json.RawMessage("null")})
+	}
+
+	value, err := json.Marshal(this.value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(expiringJSON{this.expiry, this.value.TypeID(), value})
+}
+
+func (this *Expiring) UnmarshalJSON(buffer []byte) error {
+	var v expiringJSON
+	if err := json.Unmarshal(buffer, &v); err != nil {
+		return err
+	}
+
+	this.expiry, this.value = v.Expiry, nil
+	if v.Type == 0 {
+		return nil
+	}
+
+	if newByID == nil || newByID(v.Type) == nil {
+		return errors.New("Error: Unknown wrapped type")
+	}
+
+	value := newByID(v.Type)
+	if err := json.Unmarshal(v.Value, value); err != nil {
+		return err
+	}
+	this.value = value
+	return nil
+}
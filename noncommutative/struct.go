@@ -0,0 +1,199 @@
+package noncommutative
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/common-lib/common"
+	"github.com/arcology-network/concurrenturl/interfaces"
+)
+
+// Struct is a fixed size tuple of byte fields. The fields can be read and written individually,
+// so the transactions touching different fields of the same record won't conflict with each other.
+type Struct struct {
+	fields  [][]byte
+	touched []bool // Fields updated since the value was loaded, only these fields will be merged into the committed value
+}
+
+func NewStruct(fields ...[]byte) interfaces.Type {
+	this := &Struct{
+		fields:  make([][]byte, len(fields)),
+		touched: make([]bool, len(fields)),
+	}
+
+	for i := range fields {
+		this.fields[i] = bytes.Clone(fields[i])
+	}
+	return this
+}
+
+func (this *Struct) NumFields() uint32 { return uint32(len(this.fields)) }
+
+func (this *Struct) GetField(idx uint32) (interface{}, error) {
+	if idx >= this.NumFields() {
+		return nil, errors.New("Error: Field index out of range")
+	}
+	return bytes.Clone(this.fields[idx]), nil
+}
+
+func (this *Struct) SetField(idx uint32, v interface{}) error {
+	if idx >= this.NumFields() {
+		return errors.New("Error: Field index out of range")
+	}
+
+	switch v := v.(type) {
+	case []byte:
+		this.fields[idx] = bytes.Clone(v)
+	case codec.Bytes:
+		this.fields[idx] = bytes.Clone(v)
+	default:
+		return errors.New("Error: Wrong field type")
+	}
+	this.touched[idx] = true
+	return nil
+}
+
+func (this *Struct) Touched() []uint32 {
+	indices := []uint32{}
+	for i, flag := range this.touched {
+		if flag {
+			indices = append(indices, uint32(i))
+		}
+	}
+	return indices
+}
+
+func (this *Struct) MemSize() uint32 {
+	return common.Accumulate(this.fields, uint32(len(this.touched)), func(v []byte) uint32 { return uint32(len(v)) })
+}
+
+func (this *Struct) IsSelf(key interface{}) bool { return true }
+func (this *Struct) TypeID() uint8               { return STRUCT }
+
+func (this *Struct) CopyTo(v interface{}) (interface{}, uint32, uint32, uint32) {
+	return v, 0, 1, 0
+}
+
+func (this *Struct) Clone() interface{} {
+	fields := make([][]byte, len(this.fields))
+	for i := range this.fields {
+		fields[i] = bytes.Clone(this.fields[i])
+	}
+
+	return &Struct{
+		fields:  fields,
+		touched: common.Clone(this.touched),
+	}
+}
+
+func (this *Struct) Equal(other interface{}) bool {
+	rhs, ok := other.(*Struct)
+	if !ok || rhs == nil || len(this.fields) != len(rhs.fields) {
+		return false
+	}
+
+	for i := range this.fields {
+		if !bytes.Equal(this.fields[i], rhs.fields[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *Struct) IsNumeric() bool     { return false }
+func (this *Struct) IsCommutative() bool { return false }
+func (this *Struct) IsBounded() bool     { return false }
+
+func (this *Struct) Value() interface{} { return this }
+func (this *Struct) Delta() interface{} { return this }
+func (this *Struct) DeltaSign() bool    { return true } // delta sign
+func (this *Struct) Min() interface{}   { return nil }
+func (this *Struct) Max() interface{}   { return nil }
+
+func (this *Struct) CloneDelta() interface{} { return this.Clone() }
+func (this *Struct) SetValue(v interface{})  { this.SetDelta(v) }
+
+func (this *Struct) IsDeltaApplied() bool       { return true }
+func (this *Struct) ResetDelta()                { common.Fill(this.touched, false) }
+func (this *Struct) SetDelta(v interface{})     { *this = *(v.(*Struct).Clone().(*Struct)) }
+func (this *Struct) SetDeltaSign(v interface{}) {}
+func (this *Struct) SetMin(v interface{})       {}
+func (this *Struct) SetMax(v interface{})       {}
+
+func (this *Struct) Get() (interface{}, uint32, uint32) {
+	return this.Clone().(*Struct).fields, 1, 0
+}
+
+func (this *Struct) New(_, delta, _, _, _ interface{}) interface{} {
+	return common.IfThenDo1st(delta != nil && delta.(*Struct) != nil, func() interface{} { return delta.(*Struct).Clone() }, interface{}(this))
+}
+
+// Assign a new value to the whole struct, all the fields are considered as updated.
+func (this *Struct) Set(value interface{}, _ interface{}) (interface{}, uint32, uint32, uint32, error) {
+	if value != nil && this != value { // Avoid self copy.
+		this.SetDelta(value)
+		common.Fill(this.touched, true)
+	}
+	return this, 0, 1, 0, nil
+}
+
+// Rebase fills the fields not touched with the ones of the committed value, so a field update doesn't
+// depend on the snapshot it was made from.
+func (this *Struct) Rebase(committed interfaces.Type) {
+	base, ok := committed.(*Struct)
+	if !ok || base == nil || len(base.fields) != len(this.fields) {
+		return
+	}
+
+	for i := range this.fields {
+		if !this.touched[i] {
+			this.fields[i] = bytes.Clone(base.fields[i])
+		}
+	}
+}
+
+// Only the touched fields are merged, so non-conflicting field updates from different transactions can all take effect.
+func (this *Struct) merge(other *Struct) {
+	if len(this.fields) != len(other.fields) { // Layout changed, a full assignment
+		this.SetDelta(other)
+		return
+	}
+
+	for i := range other.touched {
+		if other.touched[i] {
+			this.fields[i] = bytes.Clone(other.fields[i])
+			this.touched[i] = true
+		}
+	}
+}
+
+func (this *Struct) ApplyDelta(v interface{}) (interfaces.Type, int, error) {
+	vec := v.([]interfaces.Univalue)
+	for i := 0; i < len(vec); i++ {
+		v := vec[i].Value()
+		if this == nil && v != nil { // New value, only the fields touched are taken, like the updates to an existing one
+			this = &Struct{
+				fields:  make([][]byte, len(v.(*Struct).fields)),
+				touched: make([]bool, len(v.(*Struct).fields)),
+			}
+		}
+
+		if this == nil && v == nil {
+			this = nil
+		}
+
+		if this != nil && v != nil {
+			this.merge(v.(*Struct))
+		}
+
+		if this != nil && v == nil {
+			this = nil
+		}
+	}
+
+	if this == nil {
+		return nil, 0, nil
+	}
+	return this, len(vec), nil
+}
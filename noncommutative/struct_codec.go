@@ -0,0 +1,108 @@
+package noncommutative
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	codec "github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/evm/common/hexutil"
+	"github.com/arcology-network/evm/rlp"
+)
+
+func (this *Struct) HeaderSize() uint32 {
+	return 3 * codec.UINT32_LEN
+}
+
+func (this *Struct) Size() uint32 {
+	return this.HeaderSize() + uint32(len(this.touched)) + codec.Byteset(this.fields).Size()
+}
+
+func (this *Struct) Encode() []byte {
+	buffer := make([]byte, this.Size())
+	this.EncodeToBuffer(buffer)
+	return buffer
+}
+
+func (this *Struct) EncodeToBuffer(buffer []byte) int {
+	return codec.Byteset([][]byte{
+		codec.Bools(this.touched).Encode(),
+		codec.Byteset(this.fields).Encode(),
+	}).EncodeToBuffer(buffer)
+}
+
+func (this *Struct) Decode(buffer []byte) interface{} {
+	if len(buffer) == 0 {
+		return this
+	}
+
+	fields := codec.Byteset{}.Decode(buffer).(codec.Byteset)
+	values := codec.Byteset{}.Decode(fields[1]).(codec.Byteset)
+	this = &Struct{
+		fields:  make([][]byte, len(values)),
+		touched: codec.Bools{}.Decode(fields[0]).(codec.Bools),
+	}
+
+	for i := range values {
+		this.fields[i] = bytes.Clone(values[i])
+	}
+	return this
+}
+
+func (this *Struct) StorageEncode() []byte {
+	buffer, err := rlp.EncodeToBytes(this.fields)
+	if err != nil {
+		panic("Failed to encode struct")
+	}
+	return buffer
+}
+
+func (this *Struct) StorageDecode(buffer []byte) interface{} {
+	var fields [][]byte
+	if err := rlp.DecodeBytes(buffer, &fields); err != nil {
+		return nil
+	}
+	return NewStruct(fields...)
+}
+
+func (this *Struct) Reset() {}
+
+func (this *Struct) Hash(hasher func([]byte) []byte) []byte {
+	return hasher(this.Encode())
+}
+
+func (this *Struct) Print() {
+	fmt.Println("Fields: ", this.fields, "Touched: ", this.touched)
+	fmt.Println()
+}
+
+type structJSON struct {
+	Fields  []hexutil.Bytes `json:"fields"`
+	Touched []bool          `json:"touched"`
+}
+
+func (this *Struct) MarshalJSON() ([]byte, error) {
+	fields := make([]hexutil.Bytes, len(this.fields))
+	for i := range this.fields {
+		fields[i] = this.fields[i]
+	}
+	return json.Marshal(structJSON{fields, this.touched})
+}
+
+func (this *Struct) UnmarshalJSON(buffer []byte) error {
+	var v structJSON
+	if err := json.Unmarshal(buffer, &v); err != nil {
+		return err
+	}
+
+	if len(v.Touched) != len(v.Fields) {
+		return errors.New("Error: Field count mismatch")
+	}
+
+	this.fields, this.touched = make([][]byte, len(v.Fields)), v.Touched
+	for i := range v.Fields {
+		this.fields[i] = v.Fields[i]
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -74,11 +76,39 @@ func (this *ReadonlyClient) BatchGet(keys []string) ([][]byte, error) {
 		return results, nil
 
 	} else {
-		// Get from the server
-		return nil, nil
+		return this.remoteBatchGet(keys)
 	}
 }
 
+// Post all the keys in one request, the missing entries are nil.
+func (this *ReadonlyClient) remoteBatchGet(keys []string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return [][]byte{}, nil
+	}
+
+	base, err := url.Parse(this.addr)
+	if err != nil {
+		return nil, errors.New("Error: The website is unreachable !")
+	}
+	base.Path = this.path
+
+	resp, err := http.Post(base.String(), "application/octet-stream", bytes.NewReader(encodeBatchRequest(keys)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	buffer, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error: Batch request failed with %v: %s", resp.Status, buffer)
+	}
+	return decodeBatchResponse(buffer, len(keys))
+}
+
 // Ready only, do nothing
 func (*ReadonlyClient) Set(path string, v []byte) error           { return nil }
 func (*ReadonlyClient) BatchSet(paths []string, v [][]byte) error { return nil }
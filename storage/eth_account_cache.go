@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	ccmap "github.com/arcology-network/common-lib/container/map"
+)
+
+// The default number of the accounts kept in the cache after Commit.
+const DEFAULT_ACCOUNT_CACHE_SIZE = 65536
+
+// AccountCache is the account lookup of EthDataStore. The accounts are kept in the least recently used
+// order, so the clean ones can be evicted when there are more than the capacity. A capacity of 0 keeps
+// all of them.
+type AccountCache struct {
+	*ccmap.ConcurrentMap
+	capacity int
+
+	lock    sync.Mutex
+	recent  *list.List // The most recently used first
+	entries map[string]*list.Element
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func NewAccountCache(capacity int) *AccountCache {
+	return &AccountCache{
+		ConcurrentMap: ccmap.NewConcurrentMap(),
+		capacity:      capacity,
+		recent:        list.New(),
+		entries:       map[string]*list.Element{},
+	}
+}
+
+func (this *AccountCache) Capacity() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.capacity
+}
+
+// SetCapacity takes effect on the next eviction.
+func (this *AccountCache) SetCapacity(capacity int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.capacity = capacity
+}
+
+func (this *AccountCache) Get(key string, args ...interface{}) (interface{}, bool) {
+	v, ok := this.ConcurrentMap.Get(key)
+	if v == nil {
+		this.misses.Add(1)
+		return v, ok
+	}
+
+	this.hits.Add(1)
+	this.Touch(key)
+	return v, ok
+}
+
+func (this *AccountCache) Set(key string, v interface{}, args ...interface{}) error {
+	if err := this.ConcurrentMap.Set(key, v); err != nil || v != nil {
+		this.Touch(key)
+		return err
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if elem, ok := this.entries[key]; ok {
+		this.recent.Remove(elem)
+		delete(this.entries, key)
+	}
+	return nil
+}
+
+// Touch moves a cached account to the front, so it will be the last one to evict.
+func (this *AccountCache) Touch(key string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if elem, ok := this.entries[key]; ok {
+		this.recent.MoveToFront(elem)
+		return
+	}
+	this.entries[key] = this.recent.PushFront(key)
+}
+
+// Evict removes the least recently used clean accounts until there are no more than the capacity,
+// the accounts with uncommitted changes are always kept. It returns the number of the accounts evicted.
+func (this *AccountCache) Evict() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	evicted := 0
+	for elem := this.recent.Back(); elem != nil && this.capacity > 0 && this.recent.Len() > this.capacity; {
+		prev := elem.Prev()
+		key := elem.Value.(string)
+		if v, _ := this.ConcurrentMap.Get(key); v == nil || !v.(*Account).dirty {
+			this.ConcurrentMap.Set(key, nil)
+			this.recent.Remove(elem)
+			delete(this.entries, key)
+			evicted++
+		}
+		elem = prev
+	}
+	return evicted
+}
+
+// Stats returns the numbers of the cache hits and misses.
+func (this *AccountCache) Stats() (uint64, uint64) {
+	return this.hits.Load(), this.misses.Load()
+}
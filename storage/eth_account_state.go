@@ -21,6 +21,35 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
+// The trie keys are mostly hashes, so the original paths and account addresses are saved under the prefix.
+var STORAGE_PREIMAGE_PREFIX = []byte("ccurl-path-")
+
+// The shard of the key is decided by the first 4 bits.
+func shardOf(key []byte) int {
+	if len(key) == 0 {
+		return 0
+	}
+	return int(key[0] >> 4)
+}
+
+func preimageKey(key []byte) []byte { return append(common.Clone(STORAGE_PREIMAGE_PREFIX), key...) }
+
+func writePreimage(diskdbs [16]ethdb.Database, key, preimage []byte) error {
+	return diskdbs[shardOf(key)].Put(preimageKey(key), preimage)
+}
+
+func readPreimage(diskdbs [16]ethdb.Database, key []byte) ([]byte, error) {
+	return diskdbs[shardOf(key)].Get(preimageKey(key))
+}
+
+// The keys saved before the preimages were introduced have none, nil is returned for them instead of an error.
+func findPreimage(diskdbs [16]ethdb.Database, key []byte) ([]byte, error) {
+	if found, err := diskdbs[shardOf(key)].Has(preimageKey(key)); !found || err != nil {
+		return nil, err
+	}
+	return readPreimage(diskdbs, key)
+}
+
 type Account struct {
 	addr string
 	ethtypes.StateAccount
@@ -29,6 +58,7 @@ type Account struct {
 	ethdb        *ethmpt.Database
 	diskdbShards [16]ethdb.Database
 	err          error
+	dirty        bool // Updated but not committed yet
 }
 
 // The diskdbs need to able to handle concurrent accesses themselve
@@ -68,18 +98,26 @@ func (this *Account) Prove(key [32]byte) ([][]byte, error) {
 	return proofs, common.IfThen(this.err != nil, this.err, err)
 }
 
+// ProvePath returns the storage trie key of a path and the proof of it.
+func (this *Account) ProvePath(path string) ([]byte, [][]byte, error) {
+	var proofs proofList
+	key := []byte(this.storageKey(path))
+	err := this.storageTrie.Prove(key, 0, &proofs)
+	return key, proofs, common.IfThen(this.err != nil, this.err, err)
+}
+
 func (this *Account) DB(key string) ethdb.Database {
-	if len(key) == 0 {
-		return this.diskdbShards[0]
-	}
-	return this.diskdbShards[key[0]>>4]
+	return this.diskdbShards[shardOf([]byte(key))]
 }
 
-func (this *Account) storageKey(key string) string {
-	if k := ccurlcommon.UnderNative(key); len(k) > 0 {
+func (this *Account) storageKey(key string) string { return storageTrieKey(key) }
+
+// The paths under the native storage are saved as they are, the others are saved under their hashes.
+func storageTrieKey(path string) string {
+	if k := ccurlcommon.UnderNative(path); len(k) > 0 {
 		return k
 	}
-	return string(this.Hash([]byte(key)))
+	return string(crypto.Keccak256([]byte(path)))
 }
 
 func (this *Account) Has(key string) bool {
@@ -128,6 +166,14 @@ func (this *Account) Retrive(key string, T any) (interface{}, error) {
 	if T == nil { // A deletion
 		return T, nil
 	}
+
+	if noncommutative.IsExpiringEncoding(buffer) { // Saved with a TTL, the expiry is up to the caller
+		return new(noncommutative.Expiring).StorageDecode(buffer), nil
+	}
+
+	if _, ok := T.(*noncommutative.Expiring); ok { // Not saved with a TTL
+		return nil, nil
+	}
 	return T.(interfaces.Type).StorageDecode(buffer), err
 }
 
@@ -162,8 +208,57 @@ func (this *Account) UpdateAccountTrie(keys []string, typedVals []interfaces.Typ
 		return common.IfThenDo1st(typedVals[i] != nil, func() []byte { return typedVals[i].StorageEncode() }, []byte{})
 	})
 
+	for i := range k {
+		if err := writePreimage(this.diskdbShards, k[i], []byte(keys[i])); err != nil {
+			this.err = err
+		}
+	}
+
 	this.storageTrie.ParallelUpdate(k, v)
 	this.Root = this.storageTrie.Hash()
+	this.dirty = true
+}
+
+// Iterate through the storage trie with the original paths of the keys. The path is nil for the keys without a preimage.
+func (this *Account) foreach(do func(trieKey, path, value []byte) error) error {
+	iter := ethmpt.NewIterator(this.storageTrie.NodeIterator(nil))
+	for iter.Next() {
+		if len(iter.Value) == 0 {
+			continue
+		}
+
+		path, err := findPreimage(this.diskdbShards, iter.Key)
+		if err != nil {
+			return err
+		}
+
+		if err := do(common.Clone(iter.Key), path, common.Clone(iter.Value)); err != nil {
+			return err
+		}
+	}
+	return iter.Err
+}
+
+// Query iterates through the storage trie and returns the paths under the pattern with their encoded values.
+// A nil condition accepts all the paths matching the pattern. The keys without a preimage can't be matched, so they are skipped.
+func (this *Account) Query(pattern string, condition func(string, string) bool) ([]string, [][]byte, error) {
+	keys, values := []string{}, [][]byte{}
+	err := this.foreach(func(_, path, value []byte) error {
+		if path == nil {
+			return nil
+		}
+
+		if key := string(path); strings.HasPrefix(key, pattern) && (condition == nil || condition(pattern, key)) {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
 }
 
 func (this *Account) Precommit(keys []string, values []interface{}) {
@@ -187,7 +282,12 @@ func (this *Account) Commit() error {
 	if err := this.ethdb.Update(root, types.EmptyRootHash, trienode.NewWithNodeSet(nodes)); err != nil { // Move to DB dirty node set
 		return err
 	}
-	return this.ethdb.Commit(root, false) // Write to DB
+
+	if err := this.ethdb.Commit(root, false); err != nil { // Write to DB
+		return err
+	}
+	this.dirty = false
+	return nil
 }
 
 func (*Account) Decode(buffer []byte) *Account {
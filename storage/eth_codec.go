@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"github.com/arcology-network/common-lib/common"
 	"github.com/arcology-network/concurrenturl/interfaces"
 )
 
@@ -14,5 +15,8 @@ func (Rlp) Encode(key string, v interface{}) []byte {
 }
 
 func (Rlp) Decode(buffer []byte, T any) interface{} {
+	if id, ok := T.(uint8); ok { // Decode by the type ID
+		return common.IfThenDo1st(TypeOf(id) != nil, func() interface{} { return TypeOf(id).StorageDecode(buffer) }, nil)
+	}
 	return T.(interfaces.Type).StorageDecode(buffer)
 }
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"sort"
+
+	"github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/common-lib/common"
+)
+
+// The expiry index entries are saved in the first shard next to the latest root, not in the state.
+var EXPIRY_INDEX_PREFIX = []byte("ccurl-ttl-")
+
+func expiryIndexKey(height uint64) []byte {
+	return binary.BigEndian.AppendUint64(bytes.Clone(EXPIRY_INDEX_PREFIX), height)
+}
+
+// Load the index entries saved, only once.
+func (this *EthDataStore) loadExpiries() map[uint64][]string {
+	if this.expiries != nil {
+		return this.expiries
+	}
+
+	this.expiries = map[uint64][]string{}
+	iter := this.diskdbs[0].NewIterator(EXPIRY_INDEX_PREFIX, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if len(iter.Key()) != len(EXPIRY_INDEX_PREFIX)+8 {
+			continue
+		}
+		height := binary.BigEndian.Uint64(iter.Key()[len(EXPIRY_INDEX_PREFIX):])
+		this.expiries[height] = codec.Strings{}.Decode(bytes.Clone(iter.Value())).(codec.Strings)
+	}
+	return this.expiries
+}
+
+// ExpiringAt returns the keys expiring at or below the height, by their expiry heights.
+func (this *EthDataStore) ExpiringAt(height uint64) map[uint64][]string {
+	this.expiryLock.Lock()
+	defer this.expiryLock.Unlock()
+
+	due := map[uint64][]string{}
+	for h, keys := range this.loadExpiries() {
+		if h <= height {
+			due[h] = common.Clone(keys)
+		}
+	}
+	return due
+}
+
+// UpdateExpiring removes the entries at the heights first, then adds the keys to the entries at theirs.
+func (this *EthDataStore) UpdateExpiring(removed []uint64, added map[uint64][]string) error {
+	this.expiryLock.Lock()
+	defer this.expiryLock.Unlock()
+
+	expiries := this.loadExpiries()
+	batch := this.diskdbs[0].NewBatch()
+	for _, height := range removed {
+		if _, ok := added[height]; !ok {
+			if err := batch.Delete(expiryIndexKey(height)); err != nil {
+				return err
+			}
+		}
+	}
+
+	updated := map[uint64][]string{}
+	for height, keys := range added {
+		if !slices.Contains(removed, height) { // Merge with the keys indexed earlier
+			keys = append(common.Clone(expiries[height]), keys...)
+		}
+
+		keys = common.Clone(keys)
+		sort.Strings(keys)
+		updated[height] = slices.Compact(keys)
+		if err := batch.Put(expiryIndexKey(height), codec.Strings(updated[height]).Encode()); err != nil {
+			return err
+		}
+	}
+
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	for _, height := range removed {
+		delete(expiries, height)
+	}
+
+	for height, keys := range updated {
+		expiries[height] = keys
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	common "github.com/arcology-network/common-lib/common"
+	ethcommon "github.com/arcology-network/evm/common"
+	ethmpt "github.com/arcology-network/evm/trie"
+)
+
+var ErrReadonlyDataStore = errors.New("Error: The archive datastore is readonly")
+
+// ArchiveDataStore is a readonly view of the world state at a committed root, so the
+// ConcurrentUrl can read the state as of a past block. Only the reads are exposed, the
+// writes are rejected. The root stays pinned until the view is released.
+type ArchiveDataStore struct {
+	store   *EthDataStore // Shared by all the views at the same root
+	live    *EthDataStore
+	release sync.Once
+}
+
+func (this *ArchiveDataStore) IfExists(key string) bool                  { return this.store.IfExists(key) }
+func (this *ArchiveDataStore) UpdateCacheStats(vals []interface{})       { this.store.UpdateCacheStats(vals) }
+func (this *ArchiveDataStore) Encoder() func(string, interface{}) []byte { return this.store.Encoder() }
+func (this *ArchiveDataStore) Decoder() func([]byte, any) interface{}    { return this.store.Decoder() }
+func (this *ArchiveDataStore) Dump() ([]string, []interface{})           { return this.store.Dump() }
+func (this *ArchiveDataStore) Print()                                    { this.store.Print() }
+func (this *ArchiveDataStore) CheckSum() [32]byte                        { return this.store.CheckSum() }
+func (this *ArchiveDataStore) Root() [32]byte                            { return this.store.Root() }
+
+func (this *ArchiveDataStore) Retrive(key string, T any) (interface{}, error) {
+	return this.store.Retrive(key, T)
+}
+
+func (this *ArchiveDataStore) BatchRetrive(keys []string, T []any) []interface{} {
+	return this.store.BatchRetrive(keys, T)
+}
+
+func (this *ArchiveDataStore) Query(pattern string, condition func(string, string) bool) ([]string, [][]byte, error) {
+	return this.store.Query(pattern, condition)
+}
+
+func (this *ArchiveDataStore) GetProof(address ethcommon.Address, storageKeys []string) (*AccountResult, error) {
+	return this.store.GetProof(address, storageKeys)
+}
+
+func (this *ArchiveDataStore) ProvePaths(paths []string, T []any) (*AccountResult, []interface{}, error) {
+	return this.store.ProvePaths(paths, T)
+}
+
+func (this *ArchiveDataStore) Inject(string, interface{}) error          { return ErrReadonlyDataStore }
+func (this *ArchiveDataStore) BatchInject([]string, []interface{}) error { return ErrReadonlyDataStore }
+func (this *ArchiveDataStore) Precommit([]string, interface{}) [32]byte  { return this.store.latestRoot }
+func (this *ArchiveDataStore) Commit() error                             { return ErrReadonlyDataStore }
+func (this *ArchiveDataStore) Clear()                                    {}
+
+// Release unpins the root, the view can't be used after the root is pruned. Only the first call counts.
+func (this *ArchiveDataStore) Release() {
+	this.release.Do(func() { this.live.unpin(this.store.latestRoot) })
+}
+
+// Pin the root so it won't be pruned while an archive is serving it.
+func (this *EthDataStore) pin(root ethcommon.Hash) {
+	this.pinLock.Lock()
+	defer this.pinLock.Unlock()
+
+	if this.pinned == nil {
+		this.pinned = map[ethcommon.Hash]int{}
+	}
+	this.pinned[root]++
+}
+
+func (this *EthDataStore) unpin(root ethcommon.Hash) {
+	this.pinLock.Lock()
+	defer this.pinLock.Unlock()
+
+	if this.pinned[root]--; this.pinned[root] <= 0 {
+		delete(this.pinned, root)
+	}
+}
+
+func (this *EthDataStore) pinnedRoots() []ethcommon.Hash {
+	this.pinLock.Lock()
+	defer this.pinLock.Unlock()
+	return common.MapKeys(this.pinned)
+}
+
+// Archive opens the datastores at the committed roots of a live EthDataStore and keeps
+// the most recently used ones, a size of 0 keeps all of them. The roots kept are pinned,
+// so the pruning of the live datastore doesn't delete them until they are evicted or the
+// archive is closed. Each view returned pins its root too, until it is released.
+type Archive struct {
+	store *EthDataStore
+	size  int
+
+	lock   sync.Mutex
+	recent *list.List // The most recently used first
+	lookup map[[32]byte]*list.Element
+}
+
+func NewArchive(store *EthDataStore, size int) *Archive {
+	return &Archive{
+		store:  store,
+		size:   size,
+		recent: list.New(),
+		lookup: map[[32]byte]*list.Element{},
+	}
+}
+
+func (this *Archive) Len() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.recent.Len()
+}
+
+// At returns a readonly view at the root, the root must have been committed. The view
+// needs releasing once it is no longer used.
+func (this *Archive) At(root [32]byte) (*ArchiveDataStore, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if elem, ok := this.lookup[root]; ok {
+		this.recent.MoveToFront(elem)
+		this.store.pin(root)
+		return &ArchiveDataStore{store: elem.Value.(*EthDataStore), live: this.store}, nil
+	}
+
+	this.store.pin(root) // Before opening, so it can't be pruned in between
+	trie, err := ethmpt.New(ethmpt.TrieID(root), this.store.ethdb)
+	if err != nil {
+		this.store.unpin(root)
+		return nil, err
+	}
+
+	store := &EthDataStore{
+		worldStateTrie: trie,
+		acctLookup:     NewAccountCache(DEFAULT_ACCOUNT_CACHE_SIZE),
+		ethdb:          this.store.ethdb,
+		diskdbs:        this.store.diskdbs,
+		latestRoot:     root,
+		encoder:        this.store.encoder,
+		decoder:        this.store.decoder,
+	}
+
+	this.store.pin(root) // One for the archive, one for the view
+	this.lookup[root] = this.recent.PushFront(store)
+	for this.size > 0 && this.recent.Len() > this.size {
+		evicted := this.recent.Remove(this.recent.Back()).(*EthDataStore).latestRoot
+		delete(this.lookup, evicted)
+		this.store.unpin(evicted) // The views not released yet still hold it
+	}
+	return &ArchiveDataStore{store: store, live: this.store}, nil
+}
+
+// Close releases the roots kept by the archive, the views not released yet still hold theirs.
+func (this *Archive) Close() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for root := range this.lookup {
+		this.store.unpin(root)
+	}
+	this.recent.Init()
+	this.lookup = map[[32]byte]*list.Element{}
+}
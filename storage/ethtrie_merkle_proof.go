@@ -1,21 +1,32 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
-	ccmap "github.com/arcology-network/common-lib/container/map"
+	common "github.com/arcology-network/common-lib/common"
+	"github.com/arcology-network/common-lib/merkle"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
 	ethcommon "github.com/arcology-network/evm/common"
 	"github.com/arcology-network/evm/common/hexutil"
 	"github.com/arcology-network/evm/core/types"
 	"github.com/arcology-network/evm/crypto"
+	"github.com/arcology-network/evm/ethdb"
+	"github.com/arcology-network/evm/ethdb/memorydb"
+	"github.com/arcology-network/evm/rlp"
 	ethmpt "github.com/arcology-network/evm/trie"
+	"github.com/holiman/uint256"
 	// ethapi "github.com/arcology-network/evm/internal/ethapi"
 )
 
 type AccountResult struct {
 	Address      ethcommon.Address `json:"address"`
+	AccountKey   hexutil.Bytes     `json:"accountKey,omitempty"` // The key in the world trie, if not the address
 	AccountProof []string          `json:"accountProof"`
 	Balance      *hexutil.Big      `json:"balance"`
 	CodeHash     ethcommon.Hash    `json:"codeHash"`
@@ -28,6 +39,9 @@ type StorageResult struct {
 	Key   string       `json:"key"`
 	Value *hexutil.Big `json:"value"`
 	Proof []string     `json:"proof"`
+
+	Path string        `json:"path,omitempty"` // The concurrenturl path the key is mapped from
+	Data hexutil.Bytes `json:"data,omitempty"` // The encoded value of the path
 }
 
 type proofList [][]byte
@@ -77,34 +91,37 @@ func LoadDataStore(ethdb *ethmpt.Database, root [32]byte) (*EthDataStore, error)
 	return &EthDataStore{
 		ethdb: ethdb,
 		// diskdbs:        ,
-		acctLookup:     ccmap.NewConcurrentMap(),
+		acctLookup:     NewAccountCache(DEFAULT_ACCOUNT_CACHE_SIZE),
 		worldStateTrie: trie,
 		encoder:        Rlp{}.Encode,
 		decoder:        Rlp{}.Decode,
 	}, nil
 }
 
+// The datastore loaded has no disk databases to open the accounts with, EthDataStore.GetProof proves them at the latest root.
 func GetProof(ethdb *ethmpt.Database, address ethcommon.Address, storageKeys []string, rootHash [32]byte) (*AccountResult, error) {
 	datastore, err := LoadDataStore(ethdb, rootHash)
 	if datastore == nil || err != nil {
 		return nil, err
 	}
+	return datastore.GetProof(address, storageKeys)
+}
 
-	account, err := datastore.GetAccount(string(address[:]), new(ethmpt.AccessListCache))
+// GetProof proves the account saved under the raw address and the storage slots in hex at the current root.
+func (this *EthDataStore) GetProof(address ethcommon.Address, storageKeys []string) (*AccountResult, error) {
+	account, err := this.GetAccount(string(address[:]), new(ethmpt.AccessListCache))
 	if err != nil {
 		return nil, err
 	}
 
+	balance, nonce := new(big.Int), uint64(0)
 	storageHash := types.EmptyRootHash
-	codeHash := account.GetCodeHash()
+	codeHash := crypto.Keccak256Hash(nil) // no account means the codeHash is the hash of an empty bytearray.
 	storageProof := make([]StorageResult, len(storageKeys))
 
-	storageTrie := account.storageTrie
-	if storageTrie != nil {
-		storageHash = storageTrie.Hash()
-	} else {
-		// no storageTrie means the account does not exist, so the codeHash is the hash of an empty bytearray.
-		codeHash = crypto.Keccak256Hash(nil)
+	if account != nil {
+		balance, nonce = account.StateAccount.Balance, account.StateAccount.Nonce
+		storageHash, codeHash = account.storageTrie.Hash(), account.GetCodeHash()
 	}
 
 	for i, hexKey := range storageKeys {
@@ -112,21 +129,25 @@ func GetProof(ethdb *ethmpt.Database, address ethcommon.Address, storageKeys []s
 		if err != nil {
 			return nil, err
 		}
-		if storageTrie != nil {
+		if account != nil {
 			proof, storageError := account.Prove(key)
 			if storageError != nil {
 				return nil, storageError
 			}
 
 			v, _ := account.storageTrie.Get(key[:]) // Get the storage value
-			storageProof[i] = StorageResult{hexKey, (*hexutil.Big)(ethcommon.BytesToHash(v).Big()), toHexSlice(proof)}
+			storageProof[i] = StorageResult{Key: hexKey, Value: (*hexutil.Big)(ethcommon.BytesToHash(v).Big()), Proof: toHexSlice(proof)}
 		} else {
-			storageProof[i] = StorageResult{hexKey, &hexutil.Big{}, []string{}}
+			storageProof[i] = StorageResult{Key: hexKey, Value: &hexutil.Big{}, Proof: []string{}}
 		}
 	}
 
-	// create the accountProof
-	accountProof, proofErr := datastore.Prove(address)
+	// create the accountProof, a missing account is proven absent under all its keys
+	accountProof, proofErr := this.Prove(address)
+	if account == nil {
+		accountProof, proofErr = this.proveAbsence(address)
+	}
+
 	if proofErr != nil {
 		return nil, proofErr
 	}
@@ -134,10 +155,274 @@ func GetProof(ethdb *ethmpt.Database, address ethcommon.Address, storageKeys []s
 	return &AccountResult{
 		Address:      address,
 		AccountProof: toHexSlice(accountProof),
-		Balance:      (*hexutil.Big)(account.StateAccount.Balance),
+		Balance:      (*hexutil.Big)(balance),
 		CodeHash:     codeHash,
-		Nonce:        hexutil.Uint64(account.StateAccount.Nonce),
+		Nonce:        hexutil.Uint64(nonce),
 		StorageHash:  storageHash,
 		StorageProof: storageProof,
 	}, nil // state.Error()
 }
+
+// ProvePaths proves the concurrenturl paths under the same account at the latest root, so it fails on an account
+// with uncommitted changes. The paths are mapped to the storage trie keys the way they are saved, the ones under the
+// native storage as they are and the others by their hashes. The balance, the nonce and the code are proven by the
+// account proof alone. The values are decoded into the types in T, one for each of the paths. The storage results
+// carry the encoded values in Data, their Values are left nil.
+func (this *EthDataStore) ProvePaths(paths []string, T []any) (*AccountResult, []interface{}, error) {
+	if len(paths) == 0 || len(paths) != len(T) {
+		return nil, nil, errors.New("Error: The paths and the types don't match")
+	}
+
+	_, accountKey, _ := ccurlcommon.ParseAccountAddr(paths[0])
+	if len(accountKey) == 0 {
+		return nil, nil, errors.New("Error: No account in the path " + paths[0])
+	}
+
+	for _, path := range paths[1:] {
+		if _, acct, _ := ccurlcommon.ParseAccountAddr(path); acct != accountKey {
+			return nil, nil, errors.New("Error: The paths aren't under the same account " + path)
+		}
+	}
+
+	accesses := ethmpt.AccessListCache{}
+	trieKey, _, err := this.accountTrieKey(accountKey, &accesses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var accountProof proofList
+	if err := this.worldStateTrie.Prove(trieKey, 0, &accountProof); err != nil {
+		return nil, nil, err
+	}
+
+	result := &AccountResult{
+		Address:      ethcommon.HexToAddress(accountKey),
+		AccountKey:   trieKey,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(new(big.Int)),
+		CodeHash:     crypto.Keccak256Hash(nil),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: make([]StorageResult, len(paths)),
+	}
+
+	values := make([]interface{}, len(paths))
+	account, err := this.GetAccount(accountKey, &accesses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if account == nil { // The account doesn't exist, the account proof proves the absence under all its keys.
+		absence, err := this.proveAbsence(result.Address)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result.AccountProof = toHexSlice(absence)
+		for i, path := range paths {
+			result.StorageProof[i] = StorageResult{Proof: []string{}, Path: path}
+		}
+		return result, values, nil
+	}
+
+	if account.dirty {
+		return nil, nil, errors.New("Error: The account has uncommitted changes " + accountKey)
+	}
+
+	result.Balance = (*hexutil.Big)(account.StateAccount.Balance)
+	result.Nonce = hexutil.Uint64(account.StateAccount.Nonce)
+	result.CodeHash = account.GetCodeHash()
+	result.StorageHash = account.storageTrie.Hash()
+
+	for i, path := range paths {
+		if values[i], err = account.Retrive(path, T[i]); err != nil {
+			return nil, nil, err
+		}
+
+		if strings.HasSuffix(path, "/balance") || strings.HasSuffix(path, "/nonce") || strings.HasSuffix(path, "/code") {
+			result.StorageProof[i] = StorageResult{Proof: []string{}, Path: path}
+			continue
+		}
+
+		key, proof, err := account.ProvePath(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, _ := account.storageTrie.Get(key)
+		result.StorageProof[i] = StorageResult{Key: hexutil.Encode(key), Proof: toHexSlice(proof), Path: path, Data: data}
+	}
+	return result, values, nil
+}
+
+// Put the proof nodes into a database under their hashes, where the trie looks for them.
+func proofDB(proof []string) (ethdb.KeyValueReader, error) {
+	db := memorydb.New()
+	for _, node := range proof {
+		buffer, err := hexutil.Decode(node)
+		if err != nil {
+			return nil, err
+		}
+		db.Put(crypto.Keccak256(buffer), buffer)
+	}
+	return db, nil
+}
+
+// The keys an account can be saved under in the world trie, the hashes of the hex address as written by Precommit,
+// the hex address itself and the raw address as proven by GetProof.
+func accountTrieKeys(address ethcommon.Address) [][]byte {
+	keys := [][]byte{}
+	for _, hexAddr := range []string{hex.EncodeToString(address[:]), address.Hex()[2:]} {
+		keys = append(keys, merkle.Sha256{}.Hash([]byte(hexAddr)), []byte(hexAddr))
+	}
+	return append(keys, address[:])
+}
+
+// Prove the absence of an account under all the keys it can be saved under, the nodes shared are kept once.
+func (this *EthDataStore) proveAbsence(address ethcommon.Address) ([][]byte, error) {
+	proof, added := proofList{}, map[string]bool{}
+	for _, key := range accountTrieKeys(address) {
+		var nodes proofList
+		if err := this.worldStateTrie.Prove(key, 0, &nodes); err != nil {
+			return nil, err
+		}
+
+		for _, node := range nodes {
+			if !added[string(node)] {
+				added[string(node)] = true
+				proof = append(proof, node)
+			}
+		}
+	}
+	return proof, nil
+}
+
+// VerifyAccountProof checks the account proof in the result against the state root and returns the account
+// state proven. The fields in the result must match the state. The account key in the result must be one of
+// the keys of the address, without one all the keys are tried. An account is only treated as missing if it is
+// proven to be absent under all the keys, an empty state is returned for it.
+func VerifyAccountProof(root ethcommon.Hash, result *AccountResult) (*types.StateAccount, error) {
+	db, err := proofDB(result.AccountProof)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, candidates := accountTrieKeys(result.Address), accountTrieKeys(result.Address)
+	if len(result.AccountKey) > 0 {
+		if pos, _ := common.FindFirstIf(keys, func(key []byte) bool { return bytes.Equal(key, result.AccountKey) }); pos < 0 {
+			return nil, errors.New("Error: The account key doesn't belong to the address " + result.Address.Hex())
+		}
+		candidates = [][]byte{result.AccountKey}
+	}
+
+	var buffer []byte
+	for _, key := range candidates {
+		if v, err := ethmpt.VerifyProof(root, key, db); err == nil && len(v) > 0 {
+			buffer = v
+			break
+		}
+	}
+
+	for i := 0; i < len(keys) && len(buffer) == 0; i++ { // Absent under every key, or it can't be proven
+		if v, err := ethmpt.VerifyProof(root, keys[i], db); err != nil || len(v) > 0 {
+			return nil, errors.New("Error: Failed to verify the account proof " + result.Address.Hex())
+		}
+	}
+
+	state := EmptyAccountState()
+	if len(buffer) > 0 {
+		if err := rlp.DecodeBytes(buffer, &state); err != nil {
+			return nil, err
+		}
+	}
+
+	balance := common.IfThen(result.Balance != nil, result.Balance.ToInt(), new(big.Int))
+	if balance.Cmp(state.Balance) != 0 || uint64(result.Nonce) != state.Nonce {
+		return nil, errors.New("Error: The balance or the nonce doesn't match the proof")
+	}
+
+	if result.StorageHash != state.Root || result.CodeHash != ethcommon.BytesToHash(state.CodeHash) {
+		return nil, errors.New("Error: The storage hash or the code hash doesn't match the proof")
+	}
+	return &state, nil
+}
+
+// The balance and the nonce are encoded the same way as in Dump, the code is proven by its hash only.
+func encodeAccountField(state *types.StateAccount, path string) ([]byte, bool) {
+	switch {
+	case strings.HasSuffix(path, "/balance"):
+		balance, _ := uint256.FromBig(state.Balance)
+		v := commutative.NewUnboundedU256()
+		v.SetValue(*balance)
+		return v.StorageEncode(), true
+
+	case strings.HasSuffix(path, "/nonce"):
+		v := commutative.NewUnboundedUint64()
+		v.SetValue(state.Nonce)
+		return v.StorageEncode(), true
+
+	case strings.HasSuffix(path, "/code"):
+		return common.Clone(state.CodeHash), true
+	}
+	return nil, false
+}
+
+// VerifyStorageProof checks the account proof first and then the storage proofs against the storage root
+// proven, so the storage values are anchored to the state root. It returns the proven values, one for each
+// storage proof, nil if the key is absent. The entries of the balance, the nonce and the code paths have no
+// storage keys, their values come from the account state proven, with the code hash for the code.
+func VerifyStorageProof(root ethcommon.Hash, result *AccountResult) ([][]byte, error) {
+	state, err := VerifyAccountProof(root, result)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(result.StorageProof))
+	for i, proof := range result.StorageProof {
+		if len(proof.Path) > 0 {
+			if _, acct, _ := ccurlcommon.ParseAccountAddr(proof.Path); !strings.EqualFold(acct, hex.EncodeToString(result.Address[:])) {
+				return nil, errors.New("Error: The path isn't under the account " + proof.Path)
+			}
+		}
+
+		if len(proof.Key) == 0 {
+			var ok bool
+			if values[i], ok = encodeAccountField(state, proof.Path); !ok {
+				return nil, errors.New("Error: No storage key to verify " + proof.Path)
+			}
+			continue
+		}
+
+		var key []byte
+		if len(proof.Path) > 0 { // The key must be the one the path is saved under
+			if key = []byte(storageTrieKey(proof.Path)); !strings.EqualFold(hexutil.Encode(key), proof.Key) {
+				return nil, errors.New("Error: The storage key doesn't match the path " + proof.Path)
+			}
+		} else {
+			hash, err := decodeHash(proof.Key)
+			if err != nil {
+				return nil, err
+			}
+			key = hash[:]
+		}
+
+		if state.Root != types.EmptyRootHash || len(proof.Proof) > 0 {
+			db, err := proofDB(proof.Proof)
+			if err != nil {
+				return nil, err
+			}
+
+			if values[i], err = ethmpt.VerifyProof(state.Root, key, db); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(proof.Path) > 0 && !bytes.Equal(values[i], proof.Data) {
+			return nil, errors.New("Error: The data doesn't match the proof " + proof.Path)
+		}
+
+		if proof.Value != nil && ethcommon.BytesToHash(values[i]).Big().Cmp(proof.Value.ToInt()) != 0 {
+			return nil, errors.New("Error: The value doesn't match the proof " + proof.Key)
+		}
+	}
+	return values, nil
+}
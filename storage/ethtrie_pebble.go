@@ -0,0 +1,31 @@
+package storage
+
+// PebbleOptions are the tuning options for the Pebble backend.
+type PebbleOptions struct {
+	Cache     int // The cache size in MB
+	Handles   int // The number of the open files
+	Namespace string
+	Readonly  bool
+}
+
+func DefaultPebbleOptions() *PebbleOptions {
+	return &PebbleOptions{
+		Cache:     256,
+		Handles:   16,
+		Namespace: "arcology",
+	}
+}
+
+// NewPebbleDataStore opens or creates a Pebble backed datastore in the directory. An existing datastore
+// is reloaded at the latest root committed, the same as the LevelDB backend.
+func NewPebbleDataStore(dir string, options *PebbleOptions) (*EthDataStore, error) {
+	if options == nil {
+		options = DefaultPebbleOptions()
+	}
+
+	pebbledb, err := newPebbleDB(dir, options)
+	if err != nil {
+		return nil, err
+	}
+	return newDiskDataStore(pebbledb)
+}
@@ -0,0 +1,17 @@
+//go:build (arm64 || amd64) && !openbsd
+
+package storage
+
+import (
+	"github.com/arcology-network/evm/core/rawdb"
+	ethdb "github.com/arcology-network/evm/ethdb"
+	"github.com/arcology-network/evm/ethdb/pebble"
+)
+
+func newPebbleDB(dir string, options *PebbleOptions) (ethdb.Database, error) {
+	db, err := pebble.New(dir, options.Cache, options.Handles, options.Namespace, options.Readonly)
+	if err != nil {
+		return nil, err
+	}
+	return rawdb.NewDatabase(db), nil
+}
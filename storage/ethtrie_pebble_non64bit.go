@@ -0,0 +1,13 @@
+//go:build !((arm64 || amd64) && !openbsd)
+
+package storage
+
+import (
+	"errors"
+
+	ethdb "github.com/arcology-network/evm/ethdb"
+)
+
+func newPebbleDB(string, *PebbleOptions) (ethdb.Database, error) {
+	return nil, errors.New("Error: Pebble isn't supported on this platform")
+}
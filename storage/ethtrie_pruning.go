@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bytes"
+
+	ethcommon "github.com/arcology-network/evm/common"
+	"github.com/arcology-network/evm/core/types"
+	"github.com/arcology-network/evm/crypto"
+	"github.com/arcology-network/evm/ethdb"
+	"github.com/arcology-network/evm/rlp"
+	ethmpt "github.com/arcology-network/evm/trie"
+)
+
+// EnablePruning keeps the last N committed roots and deletes the unreachable trie nodes. The sweep runs
+// once every N commits, so up to 2N - 1 roots can be on the disks in between. The roots committed before
+// pruning is enabled aren't kept. 0 disables pruning.
+func (this *EthDataStore) EnablePruning(keep int) {
+	this.pruneKeep = keep
+	this.roots = this.roots[:0]
+}
+
+// Record the root committed and prune when there are enough roots.
+func (this *EthDataStore) trackRoot(root ethcommon.Hash) error {
+	if this.pruneKeep <= 0 || root == types.EmptyRootHash {
+		return nil
+	}
+
+	if len(this.roots) == 0 || this.roots[len(this.roots)-1] != root {
+		this.roots = append(this.roots, root)
+	}
+
+	if len(this.roots) < 2*this.pruneKeep {
+		return nil
+	}
+
+	this.roots = this.roots[len(this.roots)-this.pruneKeep:]
+	_, err := this.Prune(this.roots)
+	return err
+}
+
+// Prune is a mark and sweep over the trie nodes. Everything reachable from the roots, including the
+// storage tries and the code, is marked first, then the unmarked nodes are deleted from all the shards.
+// The roots pinned by the open archives are always kept. It returns the number of entries deleted.
+func (this *EthDataStore) Prune(roots []ethcommon.Hash) (int, error) {
+	nodes, keys := map[ethcommon.Hash]struct{}{}, map[string]struct{}{}
+	for _, root := range append(this.pinnedRoots(), roots...) {
+		if err := this.mark(root, nodes, keys); err != nil {
+			return 0, err
+		}
+	}
+
+	total := 0
+	swept := map[ethdb.Database]struct{}{} // The shards may share the same database
+	for _, diskdb := range this.diskdbs {
+		if _, ok := swept[diskdb]; ok || diskdb == nil {
+			continue
+		}
+		swept[diskdb] = struct{}{}
+
+		deleted, err := sweep(diskdb, nodes, keys)
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+	}
+	return total, nil
+}
+
+// Mark the world trie nodes and the nodes of the account storage tries under it, and the trie keys
+// for their preimages.
+func (this *EthDataStore) mark(root ethcommon.Hash, nodes map[ethcommon.Hash]struct{}, keys map[string]struct{}) error {
+	trie, err := ethmpt.New(ethmpt.TrieID(root), this.ethdb)
+	if err != nil {
+		return err
+	}
+
+	return markTrie(trie, nodes, keys, func(value []byte) error {
+		var state types.StateAccount
+		if err := rlp.DecodeBytes(value, &state); err != nil {
+			return err
+		}
+		nodes[ethcommon.BytesToHash(state.CodeHash)] = struct{}{}
+
+		if state.Root == types.EmptyRootHash {
+			return nil
+		}
+
+		storageTrie, err := ethmpt.NewParallel(ethmpt.TrieID(state.Root), this.ethdb)
+		if err != nil {
+			return err
+		}
+		return markTrie(storageTrie, nodes, keys, nil)
+	})
+}
+
+func markTrie(trie *ethmpt.Trie, nodes map[ethcommon.Hash]struct{}, keys map[string]struct{}, onLeaf func([]byte) error) error {
+	iter := trie.NodeIterator(nil)
+	for iter.Next(true) {
+		if hash := iter.Hash(); hash != (ethcommon.Hash{}) {
+			nodes[hash] = struct{}{}
+		}
+
+		if !iter.Leaf() {
+			continue
+		}
+
+		keys[string(iter.LeafKey())] = struct{}{}
+		if onLeaf != nil {
+			if err := onLeaf(iter.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	return iter.Error()
+}
+
+// Only the entries saved under their own hashes, the trie nodes and the code, and the preimages are swept.
+func sweep(diskdb ethdb.Database, nodes map[ethcommon.Hash]struct{}, keys map[string]struct{}) (int, error) {
+	unreachable := [][]byte{}
+	iter := diskdb.NewIterator(nil, nil)
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) == ethcommon.HashLength {
+			if _, ok := nodes[ethcommon.BytesToHash(key)]; !ok && bytes.Equal(crypto.Keccak256(iter.Value()), key) {
+				unreachable = append(unreachable, bytes.Clone(key))
+			}
+			continue
+		}
+
+		if bytes.HasPrefix(key, STORAGE_PREIMAGE_PREFIX) {
+			if _, ok := keys[string(key[len(STORAGE_PREIMAGE_PREFIX):])]; !ok {
+				unreachable = append(unreachable, bytes.Clone(key))
+			}
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+
+	batch := diskdb.NewBatch()
+	for _, key := range unreachable {
+		if err := batch.Delete(key); err != nil {
+			return 0, err
+		}
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return 0, err
+			}
+			batch.Reset()
+		}
+	}
+	return len(unreachable), batch.Write()
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	ethcommon "github.com/arcology-network/evm/common"
+	"github.com/arcology-network/evm/core/rawdb"
+	"github.com/arcology-network/evm/core/types"
+	ethdb "github.com/arcology-network/evm/ethdb"
+	"github.com/arcology-network/evm/rlp"
+	ethmpt "github.com/arcology-network/evm/trie"
+)
+
+// ShardDir is the directory of a shard under the dir.
+func ShardDir(dir string, shard int) string {
+	return filepath.Join(dir, fmt.Sprintf("shard-%02d", shard))
+}
+
+// NewShardedLevelDBDataStore opens an independent LevelDB for each of the 16 shards, one directory
+// per shard under the dir. An existing datastore is reloaded at the latest root committed.
+func NewShardedLevelDBDataStore(dir string) (*EthDataStore, error) {
+	diskdbs := [16]ethdb.Database{}
+	for i := range diskdbs {
+		leveldb, err := rawdb.NewLevelDBDatabase(ShardDir(dir, i), 16, 16, fmt.Sprintf("arcology/shard-%02d", i), false)
+		if err != nil {
+			for _, diskdb := range diskdbs[:i] {
+				diskdb.Close()
+			}
+			return nil, err
+		}
+		diskdbs[i] = leveldb
+	}
+	return newShardedDataStore(diskdbs)
+}
+
+// MigrateToSharded copies the state at the latest root of a datastore, usually one with a single database,
+// into the sharded layout under the dir. The older roots aren't copied.
+func MigrateToSharded(src *EthDataStore, dir string) (*EthDataStore, error) {
+	dst, err := NewShardedLevelDBDataStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := src.migrate(dst.diskdbs); err != nil {
+		dst.Close()
+		return nil, err
+	}
+
+	if err := dst.LoadRoot(src.latestRoot); err != nil {
+		dst.Close()
+		return nil, err
+	}
+	return dst, nil
+}
+
+// The trie nodes go to the shards the parallel trie database looks for them, by the first nibble
+// of the paths, or by the hashes for the roots. The code and the preimages are routed the same way
+// as the accounts do, the expiry index goes to the first shard. The keys without preimages are carried
+// over as they are, the code of an account without one goes to all the shards, as its shard is unknown.
+func (this *EthDataStore) migrate(diskdbs [16]ethdb.Database) error {
+	batches := [16]ethdb.Batch{}
+	for i := range batches {
+		batches[i] = diskdbs[i].NewBatch()
+	}
+
+	put := func(shard int, key, value []byte) error {
+		if err := batches[shard].Put(key, bytes.Clone(value)); err != nil {
+			return err
+		}
+
+		if batches[shard].ValueSize() >= ethdb.IdealBatchSize {
+			if err := batches[shard].Write(); err != nil {
+				return err
+			}
+			batches[shard].Reset()
+		}
+		return nil
+	}
+
+	if this.latestRoot != (ethcommon.Hash{}) && this.latestRoot != types.EmptyRootHash {
+		trie, err := ethmpt.New(ethmpt.TrieID(this.latestRoot), this.ethdb)
+		if err != nil {
+			return err
+		}
+
+		if err := migrateTrie(trie, this.diskdbs, put, func(key, value []byte) error {
+			var state types.StateAccount
+			if err := rlp.DecodeBytes(value, &state); err != nil {
+				return err
+			}
+
+			addr, err := findPreimage(this.diskdbs, key)
+			if err != nil {
+				return err
+			}
+
+			account := this.loadAccount(string(addr), state)
+			if account.err != nil {
+				return account.err
+			}
+
+			shards := []int{shardOf([]byte(ccurlcommon.ETH10_ACCOUNT_PREFIX + account.addr + "/code"))}
+			if addr == nil && !bytes.Equal(state.CodeHash, types.EmptyCodeHash[:]) {
+				account.code, shards = findCode(this.diskdbs, state.CodeHash), []int{}
+				for i := range diskdbs {
+					shards = append(shards, i)
+				}
+			}
+
+			for i := 0; i < len(shards) && len(account.code) > 0; i++ {
+				if err := put(shards[i], state.CodeHash, account.code); err != nil {
+					return err
+				}
+			}
+			return migrateTrie(account.storageTrie, this.diskdbs, put, nil)
+		}); err != nil {
+			return err
+		}
+
+		if err := put(0, LATEST_ROOT_KEY, this.latestRoot[:]); err != nil {
+			return err
+		}
+	}
+
+	iter := this.diskdbs[0].NewIterator(EXPIRY_INDEX_PREFIX, nil) // The expiry index isn't in the state
+	defer iter.Release()
+	for iter.Next() {
+		if err := put(0, iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+
+	for i := range batches {
+		if err := batches[i].Write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Copy the nodes and the preimages of the leaf keys in a trie.
+func migrateTrie(trie *ethmpt.Trie, src [16]ethdb.Database, put func(int, []byte, []byte) error, onLeaf func([]byte, []byte) error) error {
+	iter := trie.NodeIterator(nil)
+	for iter.Next(true) {
+		if hash := iter.Hash(); hash != (ethcommon.Hash{}) {
+			shard := shardOf(hash[:])
+			if path := iter.Path(); len(path) > 0 {
+				shard = int(path[0])
+			}
+
+			if err := put(shard, hash[:], iter.NodeBlob()); err != nil {
+				return err
+			}
+		}
+
+		if !iter.Leaf() {
+			continue
+		}
+
+		key := iter.LeafKey()
+		preimage, err := findPreimage(src, key)
+		if err != nil {
+			return err
+		}
+
+		if preimage != nil {
+			if err := put(shardOf(key), preimageKey(key), preimage); err != nil {
+				return err
+			}
+		}
+
+		if onLeaf != nil {
+			if err := onLeaf(key, iter.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	return iter.Error()
+}
+
+// Look for the code in all the shards, for the accounts whose addresses are unknown.
+func findCode(diskdbs [16]ethdb.Database, codeHash []byte) []byte {
+	for _, diskdb := range diskdbs {
+		if code, err := diskdb.Get(codeHash); err == nil && len(code) > 0 {
+			return code
+		}
+	}
+	return nil
+}
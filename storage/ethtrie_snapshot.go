@@ -0,0 +1,304 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	codec "github.com/arcology-network/common-lib/codec"
+	common "github.com/arcology-network/common-lib/common"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	ethcommon "github.com/arcology-network/evm/common"
+	"github.com/arcology-network/evm/core/types"
+	"github.com/arcology-network/evm/rlp"
+	ethmpt "github.com/arcology-network/evm/trie"
+)
+
+// The state snapshot starts with the magic and the root, followed by the length prefixed accounts.
+const STATE_SNAPSHOT_MAGIC = uint32(0x57A7E5AF)
+
+// The limit on the size of a single account record, the larger ones are rejected before being read.
+var MAX_SNAPSHOT_RECORD_SIZE uint32 = 64 << 20
+
+var (
+	ErrRootMismatch           = errors.New("Error: The root hash doesn't match after restore")
+	ErrSnapshotRecordTooLarge = errors.New("Error: The account record exceeds the max record size")
+)
+
+// Iterate through the accounts in the world trie, with the keys they are saved under. The address of
+// an account is empty if the key has no preimage.
+func (this *EthDataStore) foreachAccount(do func(trieKey []byte, account *Account) error) error {
+	iter := ethmpt.NewIterator(this.worldStateTrie.NodeIterator(nil))
+	for iter.Next() {
+		if len(iter.Value) == 0 {
+			continue
+		}
+
+		addr, err := findPreimage(this.diskdbs, iter.Key)
+		if err != nil {
+			return err
+		}
+
+		if v, _ := this.acctLookup.Get(string(addr)); addr != nil && v != nil {
+			if err := do(common.Clone(iter.Key), v.(*Account)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var state types.StateAccount
+		if err := rlp.DecodeBytes(iter.Value, &state); err != nil {
+			return err
+		}
+
+		account := this.loadAccount(string(addr), state)
+		if account.err != nil {
+			return account.err
+		}
+
+		if err := do(common.Clone(iter.Key), account); err != nil {
+			return err
+		}
+	}
+	return iter.Err
+}
+
+// Dump returns all the paths in the world state with their encoded values. The balance, the nonce and the code
+// are encoded the same way as the values in the storage tries. The accounts and the storage keys without
+// preimages have no paths, so they are left out.
+func (this *EthDataStore) Dump() ([]string, []interface{}) {
+	keys, values := []string{}, []interface{}{}
+	this.foreachAccount(func(_ []byte, account *Account) error {
+		if len(account.addr) == 0 {
+			return nil
+		}
+
+		prefix := ccurlcommon.ETH10_ACCOUNT_PREFIX + account.addr
+
+		balance, _ := account.Retrive(prefix+"/balance", nil)
+		nonce, _ := account.Retrive(prefix+"/nonce", nil)
+		keys = append(keys, prefix+"/balance", prefix+"/nonce")
+		values = append(values, balance.(interfaces.Type).StorageEncode(), nonce.(interfaces.Type).StorageEncode())
+
+		if len(account.code) > 0 {
+			keys, values = append(keys, prefix+"/code"), append(values, common.Clone(account.code))
+		}
+
+		return account.foreach(func(_, path, value []byte) error {
+			if path != nil {
+				keys, values = append(keys, string(path)), append(values, value)
+			}
+			return nil
+		})
+	})
+
+	common.SortBy1st(keys, values, func(lhv, rhv string) bool { return lhv < rhv })
+	return keys, values
+}
+
+func (this *EthDataStore) CheckSum() [32]byte {
+	k, vs := this.Dump()
+	kData := codec.Strings(k).Flatten()
+	vData := make([][]byte, len(vs))
+	for i, v := range vs {
+		vData[i] = v.([]byte)
+	}
+	vData = append(vData, kData)
+	return sha256.Sum256(codec.Byteset(vData).Flatten())
+}
+
+// Export writes the committed world state, including the accounts, the code and the storage tries,
+// so an identical datastore can be rebuilt by Import. The keys without preimages are exported with
+// empty addresses or paths, and restored under the same trie keys.
+func (this *EthDataStore) Export(writer io.Writer) error {
+	header := make([]byte, 4+ethcommon.HashLength)
+	binary.LittleEndian.PutUint32(header, STATE_SNAPSHOT_MAGIC)
+	copy(header[4:], this.worldStateTrie.Hash().Bytes())
+	if _, err := writer.Write(header); err != nil {
+		return err
+	}
+
+	return this.foreachAccount(func(trieKey []byte, account *Account) error {
+		state, err := rlp.EncodeToBytes(&account.StateAccount)
+		if err != nil {
+			return err
+		}
+
+		trieKeys, paths, values := [][]byte{}, []string{}, [][]byte{}
+		if err := account.foreach(func(key, path, value []byte) error {
+			trieKeys, paths, values = append(trieKeys, key), append(paths, string(path)), append(values, value)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		buffer := codec.Byteset{
+			trieKey,
+			[]byte(account.addr),
+			state,
+			account.code,
+			codec.Strings(paths).Encode(),
+			codec.Byteset(trieKeys).Encode(),
+			codec.Byteset(values).Encode(),
+		}.Encode()
+
+		length := make([]byte, 4)
+		binary.LittleEndian.PutUint32(length, uint32(len(buffer)))
+		if _, err := writer.Write(length); err != nil {
+			return err
+		}
+		_, err = writer.Write(buffer)
+		return err
+	})
+}
+
+// Import rebuilds the world state exported by Export into an empty datastore. The state is committed
+// and the root hash is checked against the one in the snapshot.
+func (this *EthDataStore) Import(reader io.Reader) error {
+	if this.worldStateTrie.Hash() != types.EmptyRootHash {
+		return errors.New("Error: The datastore isn't empty")
+	}
+
+	header := make([]byte, 4+ethcommon.HashLength)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return err
+	}
+
+	if binary.LittleEndian.Uint32(header) != STATE_SNAPSHOT_MAGIC {
+		return errors.New("Error: Not a state snapshot")
+	}
+	root := ethcommon.BytesToHash(header[4:])
+
+	length := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, length); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		if binary.LittleEndian.Uint32(length) > MAX_SNAPSHOT_RECORD_SIZE {
+			return ErrSnapshotRecordTooLarge
+		}
+
+		size := binary.LittleEndian.Uint32(length) // Only allocated as the record is read
+		buffer, err := io.ReadAll(io.LimitReader(reader, int64(size)))
+		if err != nil {
+			return err
+		}
+
+		if len(buffer) != int(size) {
+			return io.ErrUnexpectedEOF
+		}
+
+		if err := ccurlcommon.CheckByteset(buffer); err != nil {
+			return err
+		}
+
+		if err := this.restoreAccount(codec.Byteset{}.Decode(buffer).(codec.Byteset)); err != nil {
+			return err
+		}
+	}
+
+	if err := this.Commit(); err != nil {
+		return err
+	}
+
+	if this.latestRoot != root {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+func (this *EthDataStore) restoreAccount(fields codec.Byteset) error {
+	if len(fields) != 7 {
+		return errors.New("Error: Malformed account")
+	}
+
+	var state types.StateAccount
+	if err := rlp.DecodeBytes(fields[2], &state); err != nil {
+		return err
+	}
+
+	addr := string(fields[1])
+	account := NewAccount(addr, this.diskdbs, EmptyAccountState())
+	account.Nonce, account.Balance, account.CodeHash = state.Nonce, state.Balance, state.CodeHash
+	if len(fields[3]) > 0 {
+		account.code = fields[3]
+		if err := account.DB(ccurlcommon.ETH10_ACCOUNT_PREFIX+addr+"/code").Put(state.CodeHash, account.code); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range fields[4:] {
+		if err := ccurlcommon.CheckByteset(field); err != nil {
+			return err
+		}
+	}
+
+	if paths := (codec.Strings{}).Decode(fields[4]).(codec.Strings); len(paths) > 0 {
+		trieKeys := codec.Byteset{}.Decode(fields[5]).(codec.Byteset)
+		values := codec.Byteset{}.Decode(fields[6]).(codec.Byteset)
+		if len(trieKeys) != len(paths) || len(values) != len(paths) {
+			return errors.New("Error: Malformed account storage " + addr)
+		}
+
+		for i := range trieKeys {
+			if len(paths[i]) == 0 {
+				continue
+			}
+
+			if err := writePreimage(this.diskdbs, trieKeys[i], []byte(paths[i])); err != nil {
+				return err
+			}
+		}
+		account.storageTrie.ParallelUpdate(trieKeys, values)
+		account.Root = account.storageTrie.Hash()
+	}
+
+	if account.Root != state.Root {
+		return errors.New("Error: The storage root doesn't match " + addr)
+	}
+
+	if len(addr) == 0 { // Can't be cached by the address, so it is committed directly
+		if err := account.Commit(); err != nil {
+			return err
+		}
+		return this.worldStateTrie.Update(fields[0], account.Encode())
+	}
+
+	account.dirty = true // Until committed
+	this.acctLookup.Set(addr, account)
+	if err := writePreimage(this.diskdbs, fields[0], fields[1]); err != nil {
+		return err
+	}
+	return this.worldStateTrie.Update(fields[0], account.Encode())
+}
+
+func (this *EthDataStore) ExportToFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := this.Export(writer); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func (this *EthDataStore) ImportFromFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return this.Import(bufio.NewReader(file))
+}
@@ -5,7 +5,6 @@ import (
 	"sync"
 
 	common "github.com/arcology-network/common-lib/common"
-	ccmap "github.com/arcology-network/common-lib/container/map"
 	"github.com/arcology-network/common-lib/merkle"
 	ccurlcommon "github.com/arcology-network/concurrenturl/common"
 	"github.com/arcology-network/concurrenturl/interfaces"
@@ -19,9 +18,12 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
+// The latest root is saved on Commit, so the datastores on the disks can be reloaded.
+var LATEST_ROOT_KEY = []byte("ccurl-latest-root")
+
 type EthDataStore struct {
 	worldStateTrie *ethmpt.Trie
-	acctLookup     *ccmap.ConcurrentMap
+	acctLookup     *AccountCache
 
 	ethdb      *ethmpt.Database
 	diskdbs    [16]ethdb.Database
@@ -32,6 +34,15 @@ type EthDataStore struct {
 
 	lock  sync.RWMutex
 	dbErr error
+
+	pruneKeep int              // The number of the latest roots to keep, 0 for no pruning
+	roots     []ethcommon.Hash // The roots committed since pruning was enabled
+
+	pinLock sync.Mutex
+	pinned  map[ethcommon.Hash]int // The roots served by the archives, with the number of the archives
+
+	expiryLock sync.Mutex
+	expiries   map[uint64][]string // The expiry index, loaded from the disk on first use
 }
 
 func NewParallelEthMemDataStore() *EthDataStore {
@@ -43,13 +54,14 @@ func NewParallelEthMemDataStore() *EthDataStore {
 	return &EthDataStore{
 		ethdb:          db,
 		diskdbs:        diskdbs,
-		acctLookup:     ccmap.NewConcurrentMap(),
+		acctLookup:     NewAccountCache(DEFAULT_ACCOUNT_CACHE_SIZE),
 		worldStateTrie: paraTrie,
 		encoder:        Rlp{}.Encode,
 		decoder:        Rlp{}.Decode,
 	}
 }
 
+// The datastore always starts with an empty world state, use OpenLevelDBDataStore to continue from the latest root.
 func NewLevelDBDataStore(dir string) *EthDataStore {
 	leveldb, err := rawdb.NewLevelDBDatabase(dir, 256, 16, "arcology", false)
 	if err != nil {
@@ -58,19 +70,78 @@ func NewLevelDBDataStore(dir string) *EthDataStore {
 
 	diskdbs := [16]ethdb.Database{}
 	common.Fill(diskdbs[:], leveldb)
+	return newEmptyDataStore(diskdbs)
+}
+
+// OpenLevelDBDataStore reopens the datastore at the latest root committed, or an empty one if there is none.
+func OpenLevelDBDataStore(dir string) (*EthDataStore, error) {
+	leveldb, err := rawdb.NewLevelDBDatabase(dir, 256, 16, "arcology", false)
+	if err != nil {
+		return nil, err
+	}
+	return newDiskDataStore(leveldb)
+}
+
+// The datastore is reloaded at the latest root if there is one.
+func newDiskDataStore(diskdb ethdb.Database) (*EthDataStore, error) {
+	diskdbs := [16]ethdb.Database{}
+	common.Fill(diskdbs[:], diskdb)
+	return newShardedDataStore(diskdbs)
+}
+
+func newEmptyDataStore(diskdbs [16]ethdb.Database) *EthDataStore {
 	db := ethmpt.NewParallelDatabase(diskdbs, nil)
 
 	paraTrie := ethmpt.NewEmptyParallel(db)
 	return &EthDataStore{
 		ethdb:          db,
 		diskdbs:        diskdbs,
-		acctLookup:     ccmap.NewConcurrentMap(),
+		acctLookup:     NewAccountCache(DEFAULT_ACCOUNT_CACHE_SIZE),
 		worldStateTrie: paraTrie,
 		encoder:        Rlp{}.Encode,
 		decoder:        Rlp{}.Decode,
 	}
 }
 
+func newShardedDataStore(diskdbs [16]ethdb.Database) (*EthDataStore, error) {
+	store := newEmptyDataStore(diskdbs)
+	if root, err := diskdbs[0].Get(LATEST_ROOT_KEY); err == nil && len(root) == ethcommon.HashLength {
+		if err := store.LoadRoot(ethcommon.BytesToHash(root)); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// LoadRoot reopens the world state at a committed root, the cached accounts are dropped.
+func (this *EthDataStore) LoadRoot(root [32]byte) error {
+	trie, err := ethmpt.NewParallel(ethmpt.TrieID(root), this.ethdb)
+	if err != nil {
+		return err
+	}
+
+	this.worldStateTrie, this.latestRoot = trie, root
+	this.acctLookup = NewAccountCache(this.acctLookup.Capacity())
+	return nil
+}
+
+// Close the disk databases, the shards may share the same one.
+func (this *EthDataStore) Close() error {
+	closed := map[ethdb.Database]struct{}{}
+	for _, diskdb := range this.diskdbs {
+		if _, ok := closed[diskdb]; ok || diskdb == nil {
+			continue
+		}
+		closed[diskdb] = struct{}{}
+
+		if err := diskdb.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (this *EthDataStore) Clear() {
 	var err error
 	this.worldStateTrie, err = ethmpt.NewParallel(ethmpt.TrieID(this.latestRoot), this.ethdb) // reopen the trie for future use
@@ -110,19 +181,15 @@ func (this *EthDataStore) IfExists(key string) bool {
 	}
 
 	// Not in cache, look up in the trie
-	buffer, _ := this.worldStateTrie.ThreadSafeGet([]byte(accountKey), &accesses)
-	if len(buffer) == 0 {
+	account, _ := this.GetAccountFromTrie(accountKey, &accesses)
+	if account == nil {
 		return false // Not found
 	}
 
 	if len(suffix) == 0 {
 		return true
 	}
-
-	var stateAccount types.StateAccount
-	rlp.DecodeBytes(buffer, &stateAccount)
-
-	return NewAccount(key, this.diskdbs, stateAccount).Has(key) // Load the account but don't keep it in the cache.
+	return account.Has(key) // Load the account but don't keep it in the cache.
 }
 
 func (this *EthDataStore) Inject(key string, value interface{}) error {
@@ -133,16 +200,8 @@ func (this *EthDataStore) BatchInject(keys []string, values []interface{}) error
 	for i := 0; i < len(keys); i++ {
 		_, key, _ := ccurlcommon.ParseAccountAddr(keys[i])
 
-		account, ok := this.acctLookup.Get(key)
-		if account != nil {
-			account = account.(*Account)
-		}
-
-		if v := common.FilterFirst(this.acctLookup.Get(key)); v != nil {
-			account = v.(*Account)
-		}
-
-		if !ok {
+		account, _ := this.acctLookup.Get(key)
+		if account == nil {
 			account = NewAccount(key, this.diskdbs, EmptyAccountState()) // empty account
 			this.acctLookup.Set(key, account)
 		}
@@ -157,6 +216,7 @@ func (this *EthDataStore) BatchInject(keys []string, values []interface{}) error
 
 	this.acctLookup.ForeachDo(func(k, accountTrie interface{}) {
 		this.worldStateTrie.Update([]byte(k.(string)), accountTrie.(*Account).Encode())
+		writePreimage(this.diskdbs, []byte(k.(string)), []byte(k.(string)))
 	})
 
 	// this.worldStateTrie.Hash()
@@ -174,28 +234,45 @@ func (this *EthDataStore) GetAccount(accountKey string, accesses *ethmpt.AccessL
 	return nil, errors.New("Invalid account: " + accountKey)
 }
 
+// The accounts are saved under the hashes by Precommit and under the keys by BatchInject.
 func (this *EthDataStore) GetAccountFromTrie(accountKey string, accesses *ethmpt.AccessListCache) (*Account, error) {
 	if len(accountKey) > 0 {
-		buffer, err := this.worldStateTrie.Get([]byte(accountKey))
+		_, buffer, err := this.accountTrieKey(accountKey, accesses)
 		if err == nil && len(buffer) > 0 { // Not found
 			var acctState types.StateAccount
-			rlp.DecodeBytes(buffer, &acctState)
-
-			return &Account{
-				accountKey,
-				acctState,
-				common.FilterFirst(this.diskdbs[0].Get(acctState.CodeHash)),
-				ethmpt.NewEmptyParallel(this.ethdb),
-				this.ethdb,
-				this.diskdbs,
-				nil,
-			}, nil
+			if err := rlp.DecodeBytes(buffer, &acctState); err != nil {
+				return nil, err
+			}
+
+			account := this.loadAccount(accountKey, acctState)
+			return account, account.err
 		}
 		return nil, err
 	}
 	return nil, errors.New("Empty key")
 }
 
+// Find the key the account is saved under in the world trie, the hashed key is returned if it isn't found.
+func (this *EthDataStore) accountTrieKey(accountKey string, accesses *ethmpt.AccessListCache) ([]byte, []byte, error) {
+	hashed := merkle.Sha256{}.Hash([]byte(accountKey))
+	buffer, err := this.worldStateTrie.ThreadSafeGet(hashed, accesses)
+	if err != nil || len(buffer) > 0 {
+		return hashed, buffer, err
+	}
+
+	if buffer, err = this.worldStateTrie.ThreadSafeGet([]byte(accountKey), accesses); err == nil && len(buffer) > 0 {
+		return []byte(accountKey), buffer, nil
+	}
+	return hashed, buffer, err
+}
+
+// Open the account storage trie at the root and load the code.
+func (this *EthDataStore) loadAccount(accountKey string, acctState types.StateAccount) *Account {
+	account := NewAccount(accountKey, this.diskdbs, acctState)
+	account.code, _ = account.DB(ccurlcommon.ETH10_ACCOUNT_PREFIX + accountKey + "/code").Get(acctState.CodeHash)
+	return account
+}
+
 func (this *EthDataStore) Retrive(key string, T any) (interface{}, error) {
 	accesses := ethmpt.AccessListCache{}
 	_, acct, _ := ccurlcommon.ParseAccountAddr(key) // Get the address
@@ -251,7 +328,11 @@ func (this *EthDataStore) Precommit(keys []string, values interface{}) [32]byte
 
 	keys, accts := this.acctLookup.KVs()
 	encoded := common.Append(accts, func(acct interface{}) []byte { return acct.(*Account).Encode() })
-	this.worldStateTrie.ParallelUpdate(common.Append(keys, func(key string) []byte { return merkle.Sha256{}.Hash([]byte(key)) }), encoded)
+	hashes := common.Append(keys, func(key string) []byte { return merkle.Sha256{}.Hash([]byte(key)) })
+	for i := range hashes {
+		writePreimage(this.diskdbs, hashes[i], []byte(keys[i]))
+	}
+	this.worldStateTrie.ParallelUpdate(hashes, encoded)
 
 	return this.worldStateTrie.Hash()
 }
@@ -265,6 +346,7 @@ func (this *EthDataStore) Commit() error {
 	// Save the world trie to DB
 	this.latestRoot, this.nodeBuffer = this.worldStateTrie.Commit(false) // Finalized the trie
 	if this.nodeBuffer == nil || len(this.nodeBuffer.Nodes) == 0 {
+		this.acctLookup.Evict()
 		return nil
 	}
 
@@ -277,8 +359,38 @@ func (this *EthDataStore) Commit() error {
 		return err
 	}
 
+	if err := this.diskdbs[0].Put(LATEST_ROOT_KEY, this.latestRoot[:]); err != nil {
+		return err
+	}
+
 	this.worldStateTrie, _ = ethmpt.New(ethmpt.TrieID(this.latestRoot), this.ethdb)
-	return nil
+	this.acctLookup.Evict() // Only the clean accounts
+	return this.trackRoot(this.latestRoot)
+}
+
+// SetAccountCacheSize sets the number of the accounts to keep after Commit, 0 keeps all of them.
+func (this *EthDataStore) SetAccountCacheSize(capacity int) {
+	this.acctLookup.SetCapacity(capacity)
+}
+
+// CacheStats returns the hits and misses of the account cache.
+func (this *EthDataStore) CacheStats() (uint64, uint64) {
+	return this.acctLookup.Stats()
+}
+
+// The accounts of the values accessed are moved to the front of the cache, so the active ones are kept.
+// It is called by ConcurrentUrl with the univalues committed. Only the univalues have the paths to find
+// the accounts, the other values are skipped.
+func (this *EthDataStore) UpdateCacheStats(vals []interface{}) {
+	for _, v := range vals {
+		if univ, ok := v.(interfaces.Univalue); ok && univ.GetPath() != nil {
+			if _, accountKey, _ := ccurlcommon.ParseAccountAddr(*univ.GetPath()); len(accountKey) > 0 {
+				if v, _ := this.acctLookup.ConcurrentMap.Get(accountKey); v != nil {
+					this.acctLookup.Touch(accountKey)
+				}
+			}
+		}
+	}
 }
 
 func (this *EthDataStore) DiskDBs() [16]ethdb.Database {
@@ -291,11 +403,20 @@ func (this *EthDataStore) Encoder() func(string, interface{}) []byte { return th
 func (this *EthDataStore) Decoder() func([]byte, any) interface{}    { return this.decoder }
 func (this *EthDataStore) EthDB() *ethmpt.Database                   { return this.ethdb }
 func (this *EthDataStore) Trie() *ethmpt.Trie                        { return this.worldStateTrie }
-func (this *EthDataStore) UpdateCacheStats([]interface{})            {}
-func (this *EthDataStore) Dump() ([]string, []interface{})           { return nil, nil }
 func (this *EthDataStore) GetRootHash() [32]byte                     { return this.worldStateTrie.Hash() }
 func (this *EthDataStore) Print()                                    {}
-func (this *EthDataStore) CheckSum() [32]byte                        { return [32]byte{} }
-func (this *EthDataStore) Query(string, func(string, string) bool) ([]string, [][]byte, error) {
-	return nil, nil, nil
+
+// Query only works under an account, since the account keys in the world trie are hashed.
+func (this *EthDataStore) Query(pattern string, condition func(string, string) bool) ([]string, [][]byte, error) {
+	_, accountKey, _ := ccurlcommon.ParseAccountAddr(pattern)
+	if len(accountKey) == 0 {
+		return nil, nil, errors.New("Error: The pattern must be under an account")
+	}
+
+	accesses := ethmpt.AccessListCache{}
+	account, err := this.GetAccount(accountKey, &accesses)
+	if account == nil {
+		return []string{}, [][]byte{}, err
+	}
+	return account.Query(pattern, condition)
 }
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"errors"
+	"math"
+
+	"github.com/arcology-network/common-lib/common"
+
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+)
+
+// A registered value type, all the functions are required.
+type TypeEntry struct {
+	New           func() interfaces.Type   // Create a new value with the default settings
+	Decode        func([]byte) interface{} // Decode from the transition encoding
+	StorageDecode func([]byte) interface{} // Decode from the storage encoding
+}
+
+// Indexed by the type ID. Registration should only take place during initialization,
+// the lookups aren't synchronized.
+var registry [256]*TypeEntry
+
+func init() {
+	builtins := []func() interfaces.Type{
+		commutative.NewPath,
+		commutative.NewUnboundedUint64,
+		commutative.NewUnboundedU256,
+		func() interfaces.Type { return commutative.NewInt64(math.MinInt64, math.MaxInt64).(interfaces.Type) },
+		func() interfaces.Type { return new(noncommutative.Int64) },
+		func() interfaces.Type { return noncommutative.NewString("") },
+		func() interfaces.Type { return noncommutative.NewBigint(0).(interfaces.Type) },
+		func() interfaces.Type { return noncommutative.NewBytes([]byte{}) },
+		func() interfaces.Type { return noncommutative.NewStruct() },
+		func() interfaces.Type { return new(noncommutative.Expiring) },
+	}
+
+	for _, factory := range builtins {
+		factory := factory
+		if err := RegisterType(
+			factory().TypeID(),
+			factory,
+			func(buffer []byte) interface{} { return factory().Decode(buffer) },
+			func(buffer []byte) interface{} { return factory().StorageDecode(buffer) },
+		); err != nil {
+			panic(err)
+		}
+	}
+
+	noncommutative.SetTypeDecoders( // For the wrapped values
+		func(id uint8, buffer []byte) interface{} {
+			return common.IfThenDo1st(TypeOf(id) != nil, func() interface{} { return TypeOf(id).Decode(buffer) }, nil)
+		},
+		func(id uint8, buffer []byte) interface{} {
+			return common.IfThenDo1st(TypeOf(id) != nil, func() interface{} { return TypeOf(id).StorageDecode(buffer) }, nil)
+		},
+	)
+	noncommutative.SetTypeFactory(NewType)
+}
+
+// Register a new value type, so it can be decoded and created by its ID. The IDs of the built-in types are reserved.
+func RegisterType(id uint8, factory func() interfaces.Type, decoder, storageDecoder func([]byte) interface{}) error {
+	if id == 0 {
+		return errors.New("Error: Invalid type ID")
+	}
+
+	if factory == nil || decoder == nil || storageDecoder == nil {
+		return errors.New("Error: Missing type functions")
+	}
+
+	if registry[id] != nil {
+		return errors.New("Error: The type ID has been registered already")
+	}
+
+	registry[id] = &TypeEntry{
+		New:           factory,
+		Decode:        decoder,
+		StorageDecode: storageDecoder,
+	}
+	return nil
+}
+
+// Look up a registered type, nil if not found
+func TypeOf(id uint8) *TypeEntry { return registry[id] }
+
+// Create a new value of a registered type, nil if not found
+func NewType(id uint8) interfaces.Type {
+	if entry := registry[id]; entry != nil {
+		return entry.New()
+	}
+	return nil
+}
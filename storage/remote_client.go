@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	codec "github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/concurrenturl/interfaces"
+)
+
+var (
+	ErrRemoteUnreachable = errors.New("Error: The remote datastore is unreachable")
+	ErrRemoteFailed      = errors.New("Error: The remote datastore failed")
+)
+
+// RemoteError tells which operation failed and why, it matches either ErrRemoteUnreachable
+// for the network failures or ErrRemoteFailed for the errors returned by the server.
+type RemoteError struct {
+	Op     string
+	Status int // The HTTP status, 0 if the server wasn't reached
+	Err    error
+	Cause  error
+}
+
+func (this *RemoteError) Error() string {
+	if this.Status != 0 {
+		return fmt.Sprintf("%v: %s %d %v", this.Err, this.Op, this.Status, this.Cause)
+	}
+	return fmt.Sprintf("%v: %s %v", this.Err, this.Op, this.Cause)
+}
+
+func (this *RemoteError) Unwrap() []error { return []error{this.Err, this.Cause} }
+
+// RemoteDataStore is a Datastore served by a RemoteServer in another process, so the executors
+// can share the same state service.
+type RemoteDataStore struct {
+	addr   string
+	client *http.Client
+
+	lock      sync.Mutex
+	lastErr   error
+	commitErr error // The result of the last commit, reported by Commit
+}
+
+func NewRemoteDataStore(addr string, timeout time.Duration) *RemoteDataStore {
+	return &RemoteDataStore{
+		addr:   strings.TrimSuffix(addr, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Err returns the last error from the calls that can't return one, like IfExists and Precommit.
+func (this *RemoteDataStore) Err() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.lastErr
+}
+
+func (this *RemoteDataStore) setErr(err error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.lastErr = err
+}
+
+func (this *RemoteDataStore) call(op string, body []byte) ([]byte, error) {
+	resp, err := this.client.Post(this.addr+"/"+op, "application/octet-stream", bytes.NewReader(body))
+	if err != nil {
+		return nil, &RemoteError{Op: op, Err: ErrRemoteUnreachable, Cause: err}
+	}
+	defer resp.Body.Close()
+
+	buffer, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &RemoteError{Op: op, Err: ErrRemoteUnreachable, Cause: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &RemoteError{Op: op, Status: resp.StatusCode, Err: ErrRemoteFailed, Cause: errors.New(strings.TrimSpace(string(buffer)))}
+	}
+	return buffer, nil
+}
+
+func (this *RemoteDataStore) IfExists(key string) bool {
+	buffer, err := this.call(REMOTE_EXISTS, codec.Strings{key}.Encode())
+	if err != nil {
+		this.setErr(err)
+		return false
+	}
+	return len(buffer) == 1 && buffer[0] == 1
+}
+
+func (this *RemoteDataStore) Inject(key string, v any) error {
+	return this.BatchInject([]string{key}, []any{v})
+}
+
+func (this *RemoteDataStore) BatchInject(keys []string, values []any) error {
+	encoded := make([][]byte, len(values))
+	for i, v := range values {
+		encoded[i] = Codec{}.Encode("", v)
+	}
+
+	_, err := this.call(REMOTE_INJECT, encodeKVs(keys, encoded))
+	return err
+}
+
+// The type is needed for the server to decode the stored value, there is no way to tell without it.
+func (this *RemoteDataStore) Retrive(key string, T any) (interface{}, error) {
+	if _, ok := T.(interfaces.Type); !ok {
+		return nil, errors.New("Error: The type is needed to retrive a remote value")
+	}
+
+	values, err := this.batchRetrive([]string{key}, []any{T})
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+func (this *RemoteDataStore) BatchRetrive(keys []string, T []any) []interface{} {
+	values, err := this.batchRetrive(keys, T)
+	if err != nil {
+		this.setErr(err)
+		return make([]interface{}, len(keys))
+	}
+	return values
+}
+
+// The types are sent by their IDs, so the server can decode the stored values.
+func (this *RemoteDataStore) batchRetrive(keys []string, T []any) ([]interface{}, error) {
+	if len(keys) == 0 {
+		return []interface{}{}, nil
+	}
+
+	typeIDs := make([]byte, len(keys))
+	for i := range typeIDs {
+		if i < len(T) {
+			if typed, ok := T[i].(interfaces.Type); ok {
+				typeIDs[i] = typed.TypeID()
+			}
+		}
+	}
+
+	buffer, err := this.call(REMOTE_RETRIVE, codec.Byteset{codec.Strings(keys).Encode(), typeIDs}.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := decodeBatchResponse(buffer, len(keys))
+	if err != nil {
+		return nil, &RemoteError{Op: REMOTE_RETRIVE, Err: ErrRemoteFailed, Cause: err}
+	}
+
+	values := make([]interface{}, len(keys))
+	for i := range encoded {
+		if encoded[i] != nil {
+			values[i] = Codec{}.Decode(encoded[i], nil)
+		}
+	}
+	return values, nil
+}
+
+// The values are the univalues from the importer, encoded by themselves. They are committed on the server
+// in the same request, so the commits from different clients don't interleave. Commit reports the result.
+func (this *RemoteDataStore) Precommit(keys []string, values interface{}) [32]byte {
+	var root [32]byte
+	encoded := make([][]byte, len(keys))
+	for i, v := range values.([]interface{}) {
+		encoded[i] = v.(interface{ Encode() []byte }).Encode()
+	}
+
+	buffer, err := this.call(REMOTE_COMMIT, encodeKVs(keys, encoded))
+	if err == nil && len(buffer) != len(root) {
+		err = &RemoteError{Op: REMOTE_COMMIT, Err: ErrRemoteFailed, Cause: errors.New("wrong root length")}
+	}
+
+	this.lock.Lock()
+	this.commitErr = err
+	this.lock.Unlock()
+
+	if err != nil {
+		this.setErr(err)
+		return root
+	}
+	copy(root[:], buffer)
+	return root
+}
+
+// Commit returns the result of the last precommit, which has been committed already.
+func (this *RemoteDataStore) Commit() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	err := this.commitErr
+	this.commitErr = nil
+	return err
+}
+
+// Nothing is kept on the server between the requests, so there is nothing to clear.
+func (this *RemoteDataStore) Clear() {}
+
+// The values are the bytes dumped by the served store.
+func (this *RemoteDataStore) Dump() ([]string, []interface{}) {
+	buffer, err := this.call(REMOTE_DUMP, nil)
+	if err != nil {
+		this.setErr(err)
+		return nil, nil
+	}
+
+	keys, encoded, err := decodeKVs(buffer)
+	if err != nil {
+		this.setErr(&RemoteError{Op: REMOTE_DUMP, Err: ErrRemoteFailed, Cause: err})
+		return nil, nil
+	}
+
+	values := make([]interface{}, len(encoded))
+	for i := range encoded {
+		values[i] = encoded[i]
+	}
+	return keys, values
+}
+
+func (this *RemoteDataStore) CheckSum() [32]byte {
+	var checksum [32]byte
+	buffer, err := this.call(REMOTE_CHECKSUM, nil)
+	if err == nil && len(buffer) != len(checksum) {
+		err = &RemoteError{Op: REMOTE_CHECKSUM, Err: ErrRemoteFailed, Cause: errors.New("wrong checksum length")}
+	}
+
+	if err != nil {
+		this.setErr(err)
+		return checksum
+	}
+	copy(checksum[:], buffer)
+	return checksum
+}
+
+// The condition can't be sent to the server, so it is applied to the keys matching the pattern locally.
+func (this *RemoteDataStore) Query(pattern string, condition func(string, string) bool) ([]string, [][]byte, error) {
+	buffer, err := this.call(REMOTE_QUERY, codec.Strings{pattern}.Encode())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, values, err := decodeKVs(buffer)
+	if err != nil {
+		return nil, nil, &RemoteError{Op: REMOTE_QUERY, Err: ErrRemoteFailed, Cause: err}
+	}
+
+	if condition == nil {
+		return keys, values, nil
+	}
+
+	filteredKeys, filteredValues := []string{}, [][]byte{}
+	for i := range keys {
+		if condition(pattern, keys[i]) {
+			filteredKeys, filteredValues = append(filteredKeys, keys[i]), append(filteredValues, values[i])
+		}
+	}
+	return filteredKeys, filteredValues, nil
+}
+
+func (this *RemoteDataStore) Encoder() func(string, interface{}) []byte { return Codec{}.Encode }
+func (this *RemoteDataStore) Decoder() func([]byte, any) interface{}    { return Codec{}.Decode }
+func (this *RemoteDataStore) UpdateCacheStats([]interface{})            {}
+func (this *RemoteDataStore) Print()                                    {}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"sync"
+
+	codec "github.com/arcology-network/common-lib/codec"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	"github.com/arcology-network/concurrenturl/interfaces"
+)
+
+// The operations served by RemoteServer, each one at its own path. There is no precommit or clear,
+// the values are precommitted and committed in one request, so the clients never leave a half done
+// commit on the server for the others to pick up.
+const (
+	REMOTE_EXISTS   = "exists"
+	REMOTE_INJECT   = "inject"
+	REMOTE_RETRIVE  = "retrive"
+	REMOTE_COMMIT   = "commit"
+	REMOTE_QUERY    = "query"
+	REMOTE_DUMP     = "dump"
+	REMOTE_CHECKSUM = "checksum"
+)
+
+// RemoteServer serves a local Datastore, usually an EthDataStore, to the RemoteDataStores.
+// The committed values are univalues, which are decoded by the decoder provided.
+type RemoteServer struct {
+	store   interfaces.Datastore
+	decoder func([]byte) interface{}
+	lock    sync.RWMutex // The writes are serialized, the reads wait for them
+}
+
+func NewRemoteServer(store interfaces.Datastore, decoder func([]byte) interface{}) *RemoteServer {
+	return &RemoteServer{
+		store:   store,
+		decoder: decoder,
+	}
+}
+
+func (this *RemoteServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "POST" {
+		http.Error(writer, "Error: Unsupported method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, status, err := readRequestBody(writer, request)
+	if err != nil {
+		http.Error(writer, err.Error(), status)
+		return
+	}
+
+	response, err := this.handle(path.Base(request.URL.Path), body)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/octet-stream")
+	writer.Write(response)
+}
+
+func (this *RemoteServer) handle(op string, body []byte) ([]byte, error) {
+	if op == REMOTE_EXISTS || op == REMOTE_RETRIVE || op == REMOTE_QUERY {
+		this.lock.RLock()
+		defer this.lock.RUnlock()
+	}
+
+	switch op {
+	case REMOTE_EXISTS:
+		keys, err := decodeBatchRequest(body)
+		if err != nil || len(keys) != 1 {
+			return nil, errors.New("Error: Malformed request")
+		}
+
+		if this.store.IfExists(keys[0]) {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+
+	case REMOTE_RETRIVE:
+		if ccurlcommon.CheckByteset(body) != nil {
+			return nil, errors.New("Error: Malformed request")
+		}
+
+		fields := codec.Byteset{}.Decode(body).(codec.Byteset)
+		if len(fields) != 2 || ccurlcommon.CheckByteset(fields[0]) != nil {
+			return nil, errors.New("Error: Malformed request")
+		}
+
+		keys := codec.Strings{}.Decode(fields[0]).(codec.Strings)
+		if len(keys) != len(fields[1]) {
+			return nil, errors.New("Error: Malformed request")
+		}
+
+		values := make([][]byte, len(keys))
+		for i, key := range keys {
+			T := any(nil)
+			if typed := NewType(fields[1][i]); typed != nil {
+				T = typed
+			}
+
+			v, err := this.store.Retrive(key, T)
+			if err != nil {
+				return nil, err
+			}
+
+			if v != nil {
+				values[i] = Codec{}.Encode("", v)
+			}
+		}
+		return encodeBatchResponse(values), nil
+
+	case REMOTE_INJECT, REMOTE_COMMIT:
+		keys, encoded, err := decodeKVs(body)
+		if err != nil {
+			return nil, err
+		}
+
+		this.lock.Lock()
+		defer this.lock.Unlock()
+
+		values := make([]interface{}, len(encoded))
+		if op == REMOTE_INJECT {
+			for i := range encoded {
+				values[i] = Codec{}.Decode(encoded[i], nil)
+			}
+			return []byte{}, this.store.BatchInject(keys, values)
+		}
+
+		for i := range encoded {
+			values[i] = this.decoder(encoded[i])
+		}
+
+		root := this.store.Precommit(keys, values)
+		err = this.store.Commit()
+		this.store.Clear() // Nothing is left for the next request, even if the commit failed
+		if err != nil {
+			return nil, err
+		}
+		return root[:], nil
+
+	case REMOTE_DUMP:
+		this.lock.Lock()
+		defer this.lock.Unlock()
+
+		keys, values := this.store.Dump()
+		encoded := make([][]byte, len(values))
+		for i, v := range values {
+			if encoded[i], _ = v.([]byte); encoded[i] == nil && v != nil {
+				encoded[i] = Codec{}.Encode("", v)
+			}
+		}
+		return encodeKVs(keys, encoded), nil
+
+	case REMOTE_CHECKSUM:
+		this.lock.Lock()
+		defer this.lock.Unlock()
+
+		checksum := this.store.CheckSum()
+		return checksum[:], nil
+
+	case REMOTE_QUERY:
+		patterns, err := decodeBatchRequest(body)
+		if err != nil || len(patterns) != 1 {
+			return nil, errors.New("Error: Malformed request")
+		}
+
+		keys, values, err := this.store.Query(patterns[0], func(string, string) bool { return true })
+		if err != nil {
+			return nil, err
+		}
+		return encodeKVs(keys, values), nil
+	}
+	return nil, errors.New("Error: Unknown operation " + op)
+}
+
+func encodeKVs(keys []string, values [][]byte) []byte {
+	return codec.Byteset{codec.Strings(keys).Encode(), codec.Byteset(values).Encode()}.Encode()
+}
+
+func decodeKVs(buffer []byte) ([]string, [][]byte, error) {
+	if ccurlcommon.CheckByteset(buffer) != nil {
+		return nil, nil, errors.New("Error: Malformed key value pairs")
+	}
+
+	fields := codec.Byteset{}.Decode(buffer).(codec.Byteset)
+	if len(fields) != 2 || ccurlcommon.CheckByteset(fields[0]) != nil || ccurlcommon.CheckByteset(fields[1]) != nil {
+		return nil, nil, errors.New("Error: Malformed key value pairs")
+	}
+
+	keys := codec.Strings{}.Decode(fields[0]).(codec.Strings)
+	values := [][]byte{}
+	if len(keys) > 0 {
+		values = codec.Byteset{}.Decode(fields[1]).(codec.Byteset)
+	}
+
+	if len(keys) != len(values) {
+		return nil, nil, errors.New("Error: Malformed key value pairs")
+	}
+	return keys, values, nil
+}
@@ -1,12 +1,32 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	cachedstorage "github.com/arcology-network/common-lib/cachedstorage"
+	codec "github.com/arcology-network/common-lib/codec"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
 )
 
+// The limit on the request bodies read by the servers, the larger ones are rejected before being read in full.
+var MAX_REQUEST_SIZE int64 = 64 << 20
+
+// Read the request body up to MAX_REQUEST_SIZE, the error has the HTTP status to reply with.
+func readRequestBody(writer http.ResponseWriter, request *http.Request) ([]byte, int, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, MAX_REQUEST_SIZE))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, http.StatusRequestEntityTooLarge, err
+		}
+		return nil, http.StatusBadRequest, err
+	}
+	return body, http.StatusOK, nil
+}
+
 type ReadonlyServer struct {
 	addr      string
 	dataStore *cachedstorage.DataStore
@@ -45,6 +65,7 @@ func (this *ReadonlyServer) BatchGet(paths []string) ([][]byte, error) {
 	return bytes, nil
 }
 
+// GET for a single key, POST for a batch of keys.
 func (this *ReadonlyServer) Receive(writer http.ResponseWriter, request *http.Request) {
 	switch request.Method {
 	case "GET":
@@ -54,5 +75,75 @@ func (this *ReadonlyServer) Receive(writer http.ResponseWriter, request *http.Re
 				writer.Write(this.encoder(v))
 			}
 		}
+
+	case "POST":
+		body, status, err := readRequestBody(writer, request)
+		if err != nil {
+			http.Error(writer, err.Error(), status)
+			return
+		}
+
+		keys, err := decodeBatchRequest(body)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		values := make([][]byte, len(keys))
+		for i, key := range keys {
+			if v, _ := this.dataStore.Retrive(key, nil); v != nil {
+				values[i] = this.encoder(v)
+			}
+		}
+		writer.Header().Set("Content-Type", "application/octet-stream")
+		writer.Write(encodeBatchResponse(values))
+
+	default:
+		http.Error(writer, "Error: Unsupported method", http.StatusMethodNotAllowed)
+	}
+}
+
+// The batch request is the encoded keys, the response has a flag for each key to tell the
+// missing entries from the empty ones, followed by the encoded values.
+func encodeBatchRequest(keys []string) []byte { return codec.Strings(keys).Encode() }
+
+func decodeBatchRequest(buffer []byte) ([]string, error) {
+	if len(buffer) < codec.UINT32_LEN || ccurlcommon.CheckByteset(buffer) != nil {
+		return nil, fmt.Errorf("Error: Malformed batch request")
+	}
+	return codec.Strings{}.Decode(buffer).(codec.Strings), nil
+}
+
+func encodeBatchResponse(values [][]byte) []byte {
+	flags := make([]byte, len(values))
+	for i := range values {
+		if values[i] != nil {
+			flags[i] = 1
+		}
+	}
+	return codec.Byteset{flags, codec.Byteset(values).Encode()}.Encode()
+}
+
+func decodeBatchResponse(buffer []byte, count int) ([][]byte, error) {
+	if ccurlcommon.CheckByteset(buffer) != nil {
+		return nil, fmt.Errorf("Error: Malformed batch response")
+	}
+
+	fields := codec.Byteset{}.Decode(buffer).(codec.Byteset)
+	if len(fields) != 2 || len(fields[0]) != count || ccurlcommon.CheckByteset(fields[1]) != nil {
+		return nil, fmt.Errorf("Error: Malformed batch response")
+	}
+
+	values := make([][]byte, count)
+	encoded := codec.Byteset{}.Decode(fields[1]).(codec.Byteset)
+	if len(encoded) != count {
+		return nil, fmt.Errorf("Error: Malformed batch response")
+	}
+
+	for i := range values {
+		if fields[0][i] == 1 {
+			values[i] = encoded[i]
+		}
 	}
+	return values, nil
 }
@@ -98,3 +98,62 @@ func TestAccumulatorLowerLimit(t *testing.T) {
 		t.Error("Error: There is should be a of-limit-error")
 	}
 }
+
+func TestAccumulatorUint64LowerLimit(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	counter := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/counter"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, "blcc://eth1.0/account/"+alice+"/storage/ctrn-0/", commutative.NewPath())
+	if _, err := url0.Write(0, counter, commutative.NewBoundedUint64(0, 100)); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := url0.Write(0, counter, commutative.NewUint64Delta(10)); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{0}, url0)
+
+	transitions := []interfaces.Univalue{}
+	for tx := uint32(1); tx <= 3; tx++ { // Each one is fine alone
+		urlx := ccurl.NewConcurrentUrl(store)
+		if _, err := urlx.Write(tx, counter, commutative.NewSignedUint64Delta(4, false)); err != nil {
+			t.Error(err)
+		}
+
+		if _, err := urlx.Write(tx, counter, commutative.NewSignedUint64Delta(7, false)); err == nil {
+			t.Error("Error: Should be lower than the min")
+		}
+
+		trans := indexer.Univalues(common.Clone(urlx.Export(indexer.Sorter))).To(indexer.ITCTransition{})
+		transitions = append(transitions, common.CopyIf(trans, func(v interfaces.Univalue) bool { return *v.GetPath() == counter })...)
+	}
+
+	conflicts := (&arbitrator.Accumulator{}).CheckMinMax(common.Clone(transitions[:2]))
+	if len(conflicts) != 0 {
+		t.Error("Error: There is no conflict")
+	}
+
+	conflicts = (&arbitrator.Accumulator{}).CheckMinMax(common.Clone(transitions))
+	if len(conflicts) != 1 || conflicts[0].Err.Error() != ccurlcommon.WARN_OUT_OF_LOWER_LIMIT {
+		t.Error("Error: There should be a lower limit conflict", len(conflicts))
+	}
+
+	// Increments offset the decrements when committed
+	url4 := ccurl.NewConcurrentUrl(store)
+	url4.Write(4, counter, commutative.NewUint64Delta(5))
+	url5 := ccurl.NewConcurrentUrl(store)
+	url5.Write(5, counter, commutative.NewSignedUint64Delta(3, false))
+	commitTransitions(url, []uint32{4, 5}, url4, url5)
+
+	if v, _ := ccurl.NewConcurrentUrl(store).Read(6, counter, new(commutative.Uint64)); v == nil || v.(uint64) != 12 {
+		t.Error("Error: Wrong value", v)
+	}
+}
@@ -1,7 +1,10 @@
 package ccurltest
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"math"
 	"math/big"
 	"reflect"
 	"testing"
@@ -117,6 +120,126 @@ func TestUnivaluesCodec(t *testing.T) {
 	}
 }
 
+func TestPooledTransitionImport(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	for round := 0; round < 2; round++ {
+		url1 := ccurl.NewConcurrentUrl(store)
+		url1.Write(1, root, commutative.NewPath())
+		url1.Write(1, root+"elem-0", noncommutative.NewString(fmt.Sprint(round)))
+
+		buffer := indexer.Univalues(indexer.Univalues(common.Clone(url1.Export(indexer.Sorter))).To(indexer.ITCTransition{})).Encode()
+		transitions, err := url.DecodeTransitions(buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		url.Import(transitions).Sort().Commit([]uint32{1})
+
+		if inUse, free := url.Importer().UnivaluePool().Stats(); inUse != 0 || free == 0 {
+			t.Error("Error: The decoded transitions should be reclaimed after commit", inUse, free)
+		}
+
+		if v, _ := ccurl.NewConcurrentUrl(store).Read(2, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != fmt.Sprint(round) {
+			t.Error("Error: Wrong value", v)
+		}
+
+		url2 := ccurl.NewConcurrentUrl(store) // Clean up for the next round
+		url2.Write(2, root, nil)
+		commitTransitions(url, []uint32{2}, url2)
+	}
+}
+
+func TestStreamedTransitionImport(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	url1.Write(1, root, commutative.NewPath())
+	for i := 0; i < 10; i++ {
+		url1.Write(1, root+"elem-"+fmt.Sprint(i), noncommutative.NewString(fmt.Sprint(i)))
+	}
+	transitions := indexer.Univalues(common.Clone(url1.Export(indexer.Sorter))).To(indexer.ITCTransition{})
+
+	buffer := bytes.NewBuffer(nil)
+	writer := indexer.NewUnivaluesWriter(buffer)
+	if err := writer.WriteAll(transitions); err != nil || writer.Count() != len(transitions) {
+		t.Error("Error: Failed to write the stream", err)
+	}
+
+	if total, err := url.ImportFrom(bytes.NewReader(buffer.Bytes()), 3); err != nil || total != len(transitions) {
+		t.Error("Error: Failed to import the stream", total, err)
+	}
+	url.Sort().Commit([]uint32{1})
+
+	for i := 0; i < 10; i++ {
+		if v, _ := ccurl.NewConcurrentUrl(store).Read(2, root+"elem-"+fmt.Sprint(i), new(noncommutative.String)); v == nil || v.(string) != fmt.Sprint(i) {
+			t.Error("Error: Wrong value", v)
+		}
+	}
+
+	// A truncated stream
+	if _, err := ccurl.NewConcurrentUrl(store).ImportFrom(bytes.NewReader(buffer.Bytes()[:buffer.Len()-1]), 3); err != io.ErrUnexpectedEOF {
+		t.Error("Error: Should fail on a truncated stream", err)
+	}
+
+	// An empty stream
+	if total, err := ccurl.NewConcurrentUrl(store).ImportFrom(bytes.NewReader(nil), 3); total != 0 || err != nil {
+		t.Error("Error: An empty stream has nothing to import", total, err)
+	}
+
+	// A corrupted length prefix is rejected before allocating anything
+	corrupted := common.Clone(buffer.Bytes())
+	codec.Uint32(math.MaxUint32).EncodeToBuffer(corrupted[indexer.VERSION_HEADER_LEN:])
+	if _, err := indexer.NewUnivaluesReader(bytes.NewReader(corrupted)).Read(); err != indexer.ErrRecordTooLarge {
+		t.Error("Error: Should reject the record", err)
+	}
+
+	if _, err := indexer.NewUnivaluesReader(bytes.NewReader(buffer.Bytes())).SetMaxRecordSize(8).Read(); err != indexer.ErrRecordTooLarge {
+		t.Error("Error: Should be over the limit", err)
+	}
+
+	// Zero length and corrupt records are rejected instead of decoded
+	header := common.Clone(buffer.Bytes()[:indexer.VERSION_HEADER_LEN])
+	for _, record := range [][]byte{{0, 0, 0, 0}, {4, 0, 0, 0, 1, 0, 0, 0}} {
+		if _, err := indexer.NewUnivaluesReader(bytes.NewReader(append(common.Clone(header), record...))).Read(); err == nil || err == io.EOF {
+			t.Error("Error: Should reject the record", record, err)
+		}
+	}
+}
+
+func BenchmarkPooledTransitionDecode(b *testing.B) {
+	store := cachedstorage.NewDataStore(nil, nil, nil, storage.Codec{}.Encode, storage.Codec{}.Decode)
+	transitions := []interfaces.Univalue{}
+	for i := 0; i < 1000; i++ {
+		url := ccurl.NewConcurrentUrl(store)
+		url.NewAccount(ccurlcommon.SYSTEM, datacompression.RandomAccount())
+		transitions = append(transitions, indexer.Univalues(common.Clone(url.Export(indexer.Sorter))).To(indexer.ITCTransition{})...)
+	}
+	buffer := indexer.Univalues(transitions).Encode()
+
+	pool := indexer.NewUnivaluePool("benchmark")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		indexer.Univalues{}.DecodeV2(buffer, pool.Get, pool.Put)
+		pool.Reclaim()
+	}
+}
+
 func BenchmarkRlpComparePerformance(t *testing.B) {
 	num := big.NewInt(100)
 
@@ -0,0 +1,79 @@
+package ccurltest
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/arcology-network/common-lib/common"
+	ccurl "github.com/arcology-network/concurrenturl"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	indexer "github.com/arcology-network/concurrenturl/indexer"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+)
+
+func TestTransitionCommitment(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	url1.Write(1, root, commutative.NewPath())
+	for i := 0; i < 20; i++ {
+		url1.Write(1, root+"elem-"+fmt.Sprint(i), noncommutative.NewString(fmt.Sprint(i)))
+	}
+
+	url2 := ccurl.NewConcurrentUrl(store)
+	url2.Write(2, "blcc://eth1.0/account/"+alice+"/storage/ctrn-1/", commutative.NewPath())
+
+	transitions := append(
+		indexer.Univalues(common.Clone(url1.Export(indexer.Sorter))).To(indexer.ITCTransition{}),
+		indexer.Univalues(common.Clone(url2.Export(indexer.Sorter))).To(indexer.ITCTransition{})...,
+	)
+
+	commitments := indexer.CommitTransitions(transitions)
+	if len(commitments) != 2 || commitments[1].Length() != 21 || commitments[2].Length() != 1 {
+		t.Error("Error: Wrong commitments", len(commitments))
+	}
+
+	// The root doesn't depend on the order of the transitions
+	reversed := common.Clone(transitions)
+	common.Reverse(&reversed)
+	if !bytes.Equal(indexer.NewTransitionCommitment(1, reversed).Root(), commitments[1].Root()) {
+		t.Error("Error: The root should be order independent")
+	}
+
+	// The transitions received by the committer
+	received := indexer.Univalues{}.Decode(indexer.Univalues(transitions).Encode()).(indexer.Univalues)
+	for _, v := range received {
+		proof, err := commitments[v.GetTx()].Prove(v)
+		if err != nil || !indexer.VerifyTransition(commitments[v.GetTx()].Root(), v, proof) {
+			t.Error("Error: Failed to verify", *v.GetPath(), err)
+		}
+
+		if v.GetTx() == 1 && indexer.VerifyTransition(commitments[2].Root(), v, proof) {
+			t.Error("Error: Shouldn't verify against a different root")
+		}
+	}
+
+	// A tampered transition
+	idx, _ := common.FindFirstIf(received, func(v interfaces.Univalue) bool { return *v.GetPath() == root+"elem-0" })
+	tampered := received[idx].Clone().(interfaces.Univalue)
+	proof, _ := commitments[1].Prove(tampered)
+	tampered.SetValue(noncommutative.NewString("3"))
+	if indexer.VerifyTransition(commitments[1].Root(), tampered, proof) {
+		t.Error("Error: A tampered transition shouldn't verify")
+	}
+
+	if _, err := commitments[1].Prove(tampered); err == nil {
+		t.Error("Error: A tampered transition shouldn't be provable")
+	}
+}
@@ -2,10 +2,14 @@ package ccurltest
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"os"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,12 +26,17 @@ import (
 	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
 	storage "github.com/arcology-network/concurrenturl/storage"
 	univalue "github.com/arcology-network/concurrenturl/univalue"
+	ethcommon "github.com/arcology-network/evm/common"
+	"github.com/arcology-network/evm/common/hexutil"
 	"github.com/arcology-network/evm/core/rawdb"
 	"github.com/arcology-network/evm/core/types"
+	"github.com/arcology-network/evm/crypto"
 	"github.com/arcology-network/evm/ethdb"
 	"github.com/arcology-network/evm/ethdb/memorydb"
+	"github.com/arcology-network/evm/rlp"
 	"github.com/arcology-network/evm/trie"
 	ethmpt "github.com/arcology-network/evm/trie"
+	"github.com/holiman/uint256"
 )
 
 func TestEthTrieBasic(t *testing.T) {
@@ -393,6 +402,968 @@ func TestAddThenDeletePathInEthTrie(t *testing.T) {
 	}
 }
 
+func TestBoundsPersistedAcrossBlocks(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, root, commutative.NewPath())
+	url0.Write(0, root+"u256", commutative.NewBoundedU256FromU64(0, 100))
+	url0.Write(0, root+"u64", commutative.NewBoundedUint64(0, 100))
+	url0.Write(0, root+"i64", commutative.NewInt64(-10, 100))
+	url0.Write(0, root+"i64", commutative.NewInt64Delta(-5))
+	commitTransitions(url, []uint32{0}, url0)
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	if _, err := url1.Write(1, root+"u256", commutative.NewU256Delta(uint256.NewInt(101), true)); err == nil {
+		t.Error("Error: The U256 upper limit is lost")
+	}
+
+	if _, err := url1.Write(1, root+"u64", commutative.NewUint64Delta(101)); err == nil {
+		t.Error("Error: The Uint64 upper limit is lost")
+	}
+
+	if _, err := url1.Write(1, root+"i64", commutative.NewInt64Delta(-6)); err == nil {
+		t.Error("Error: The Int64 lower limit is lost")
+	}
+
+	if v, _ := url1.Read(1, root+"i64", new(commutative.Int64)); v == nil || v.(int64) != -5 {
+		t.Error("Error: Wrong value", v)
+	}
+}
+
+func TestEthDataStoreQuery(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, root, commutative.NewPath())
+	for i := 0; i < 10; i++ {
+		url0.Write(0, root+"elem-"+fmt.Sprint(i), noncommutative.NewInt64(int64(i)))
+	}
+	commitTransitions(url, []uint32{0}, url0)
+
+	keys, values, err := store.Query(root, nil)
+	if err != nil || len(keys) != 11 || len(values) != 11 {
+		t.Error("Error: Wrong number of entries", len(keys), err)
+	}
+
+	for i, key := range keys {
+		if key == root {
+			continue
+		}
+
+		v := new(noncommutative.Int64).StorageDecode(values[i])
+		if fmt.Sprint(*v.(*noncommutative.Int64)) != key[len(root+"elem-"):] {
+			t.Error("Error: Wrong value", key, v)
+		}
+	}
+
+	// Only the elements, not the container itself
+	keys, _, _ = store.Query(root, func(pattern, key string) bool { return key != pattern })
+	if len(keys) != 10 {
+		t.Error("Error: Wrong number of entries", len(keys))
+	}
+
+	if keys, _, _ = store.Query(root+"elem-1", nil); len(keys) != 1 || keys[0] != root+"elem-1" {
+		t.Error("Error: Wrong entries", keys)
+	}
+
+	if keys, _, _ = store.Query("blcc://eth1.0/account/"+BobAccount()+"/", nil); len(keys) != 0 {
+		t.Error("Error: Should be empty", keys)
+	}
+
+	if _, _, err := store.Query("blcc://eth1.0/", nil); err == nil {
+		t.Error("Error: Should require an account")
+	}
+}
+
+// Remove the preimages, like the datastores written before they were saved.
+func dropPreimages(store *storage.EthDataStore) {
+	for _, db := range store.DiskDBs() {
+		keys := [][]byte{}
+		iter := db.NewIterator(storage.STORAGE_PREIMAGE_PREFIX, nil)
+		for iter.Next() {
+			keys = append(keys, common.Clone(iter.Key()))
+		}
+		iter.Release()
+
+		for _, key := range keys {
+			db.Delete(key)
+		}
+	}
+}
+
+func TestEthDataStoreQueryWithoutPreimages(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, root, commutative.NewPath())
+	for i := 0; i < 10; i++ {
+		url0.Write(0, root+"elem-"+fmt.Sprint(i), noncommutative.NewInt64(int64(i)))
+	}
+	commitTransitions(url, []uint32{0}, url0)
+	dropPreimages(store)
+
+	if keys, values, err := store.Query(root, nil); err != nil || len(keys) != 0 || len(values) != 0 {
+		t.Error("Error: Should skip the keys without preimages", keys, err)
+	}
+
+	// The ones written afterwards have their preimages
+	url1 := ccurl.NewConcurrentUrl(store)
+	url1.Write(1, root+"elem-10", noncommutative.NewInt64(10))
+	commitTransitions(url, []uint32{1}, url1)
+
+	keys, values, err := store.Query(root, nil)
+	if err != nil || len(keys) != 2 || len(values) != 2 || !slices.Contains(keys, root+"elem-10") {
+		t.Error("Error: Wrong entries", keys, err)
+	}
+
+	// Still readable by the paths
+	if v, _ := ccurl.NewConcurrentUrl(store).Read(2, root+"elem-3", new(noncommutative.Int64)); v == nil || v.(int64) != 3 {
+		t.Error("Error: Wrong value", v)
+	}
+}
+
+func TestEthDataStoreAccountCache(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	store.SetAccountCacheSize(2)
+	alice, bob := AliceAccount(), BobAccount()
+
+	url := ccurl.NewConcurrentUrl(store)
+	for _, acct := range []string{alice, bob} {
+		if _, err := url.NewAccount(ccurlcommon.SYSTEM, acct); err != nil {
+			t.Error(err)
+		}
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	for _, acct := range []string{alice, bob} {
+		url0.Write(0, "blcc://eth1.0/account/"+acct+"/storage/native/elem-0", noncommutative.NewString(acct))
+	}
+	commitTransitions(url, []uint32{0}, url0)
+
+	// Both fit in the cache
+	hits, misses := store.CacheStats()
+	for _, acct := range []string{alice, bob} {
+		if v, _ := store.Retrive("blcc://eth1.0/account/"+acct+"/storage/native/elem-0", new(noncommutative.String)); v == nil || string(*v.(*noncommutative.String)) != acct {
+			t.Error("Error: Wrong value", acct, v)
+		}
+	}
+
+	if h, m := store.CacheStats(); h != hits+2 || m != misses {
+		t.Error("Error: Should be cached", h-hits, m-misses)
+	}
+
+	// Alice is the most recently used one, so Bob goes first.
+	store.SetAccountCacheSize(1)
+	store.UpdateCacheStats([]interface{}{univalue.NewUnivalue(0, "blcc://eth1.0/account/"+alice+"/balance", 1, 0, 0, nil, nil)})
+	if err := store.Commit(); err != nil {
+		t.Error(err)
+	}
+
+	hits, misses = store.CacheStats()
+	for _, acct := range []string{alice, bob} {
+		if v, _ := store.Retrive("blcc://eth1.0/account/"+acct+"/storage/native/elem-0", new(noncommutative.String)); v == nil || string(*v.(*noncommutative.String)) != acct {
+			t.Error("Error: Wrong value after eviction", acct, v)
+		}
+	}
+
+	if h, m := store.CacheStats(); h != hits+1 || m != misses+1 {
+		t.Error("Error: Bob should have been evicted", h-hits, m-misses)
+	}
+
+	// The evicted account can still be updated.
+	url1 := ccurl.NewConcurrentUrl(store)
+	url1.Write(1, "blcc://eth1.0/account/"+bob+"/storage/native/elem-1", noncommutative.NewString("bob-1"))
+	commitTransitions(url, []uint32{1}, url1)
+
+	if v, _ := store.Retrive("blcc://eth1.0/account/"+bob+"/storage/native/elem-1", new(noncommutative.String)); v == nil || string(*v.(*noncommutative.String)) != "bob-1" {
+		t.Error("Error: Wrong value", v)
+	}
+
+	if v, _ := store.Retrive("blcc://eth1.0/account/"+bob+"/storage/native/elem-0", new(noncommutative.String)); v == nil || string(*v.(*noncommutative.String)) != bob {
+		t.Error("Error: Wrong value", v)
+	}
+
+	// One lookup for each key injected
+	hits, misses = store.CacheStats()
+	if err := store.Inject("blcc://eth1.0/account/"+RandomAccount()+"/storage/native/elem-0", noncommutative.NewString("0")); err != nil {
+		t.Error(err)
+	}
+
+	if h, m := store.CacheStats(); h != hits || m != misses+1 {
+		t.Error("Error: Wrong lookups", h-hits, m-misses)
+	}
+
+	cache := storage.NewAccountCache(2)
+	cache.SetCapacity(8)
+	if cache.Capacity() != 8 {
+		t.Error("Error: Wrong capacity", cache.Capacity())
+	}
+}
+
+func proofDBOf(proof []string) ethdb.KeyValueStore {
+	proofDB := memorydb.New()
+	for _, node := range proof {
+		buffer := hexutil.MustDecode(node)
+		proofDB.Put(crypto.Keccak256(buffer), buffer)
+	}
+	return proofDB
+}
+
+func TestEthDataStoreProvePaths(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	alice, bob := AliceAccount(), BobAccount()
+	prefix := "blcc://eth1.0/account/" + alice
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, prefix+"/storage/native/elem-0", noncommutative.NewString("native"))
+	url0.Write(0, prefix+"/storage/ctrn-0/", commutative.NewPath())
+	url0.Write(0, prefix+"/storage/ctrn-0/elem-0", noncommutative.NewInt64(64))
+	commitTransitions(url, []uint32{0}, url0)
+
+	paths := []string{prefix + "/nonce", prefix + "/storage/native/elem-0", prefix + "/storage/ctrn-0/elem-0", prefix + "/storage/ctrn-0/elem-1"}
+	result, values, err := store.ProvePaths(paths, []any{new(commutative.Uint64), new(noncommutative.String), new(noncommutative.Int64), new(noncommutative.Int64)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(result.AccountKey, merkle.Sha256{}.Hash([]byte(alice))) {
+		t.Error("Error: Wrong account key", result.AccountKey)
+	}
+
+	if _, err := ethmpt.VerifyProof(store.Root(), result.AccountKey, proofDBOf(result.AccountProof)); err != nil {
+		t.Error(err)
+	}
+
+	if *values[1].(*noncommutative.String) != "native" || *values[2].(*noncommutative.Int64) != 64 || values[3] != nil {
+		t.Error("Error: Wrong values", values)
+	}
+
+	// The native path is saved as it is, the others under the hashes.
+	keys := [][]byte{nil, []byte(ccurlcommon.UnderNative(paths[1])), crypto.Keccak256([]byte(paths[2])), crypto.Keccak256([]byte(paths[3]))}
+	for i, proof := range result.StorageProof {
+		if proof.Path != paths[i] || proof.Key != common.IfThen(keys[i] == nil, "", hexutil.Encode(keys[i])) {
+			t.Error("Error: Wrong storage key", proof.Path, proof.Key)
+		}
+
+		if proof.Value != nil {
+			t.Error("Error: The data carries the value", proof.Path, proof.Value)
+		}
+
+		if keys[i] == nil {
+			continue
+		}
+
+		data, err := ethmpt.VerifyProof(result.StorageHash, keys[i], proofDBOf(proof.Proof))
+		if err != nil || !bytes.Equal(data, proof.Data) {
+			t.Error("Error: Failed to verify", proof.Path, err)
+		}
+	}
+
+	if _, _, err := store.ProvePaths([]string{paths[1], "blcc://eth1.0/account/" + bob + "/nonce"}, []any{nil, nil}); err == nil {
+		t.Error("Error: Should be under the same account")
+	}
+
+	// A missing account comes with the proof of absence
+	result, _, err = store.ProvePaths([]string{"blcc://eth1.0/account/" + bob + "/nonce"}, []any{nil})
+	if err != nil || result.StorageHash != types.EmptyRootHash {
+		t.Error("Error: Should be empty", err)
+	}
+
+	if data, err := ethmpt.VerifyProof(store.Root(), result.AccountKey, proofDBOf(result.AccountProof)); err != nil || data != nil {
+		t.Error("Error: Should prove the absence", err)
+	}
+
+	// Not until the changes are committed
+	if err := store.Inject(paths[1], noncommutative.NewString("updated")); err != nil {
+		t.Error(err)
+	}
+
+	if _, _, err := store.ProvePaths(paths[1:2], []any{new(noncommutative.String)}); err == nil {
+		t.Error("Error: Should fail with uncommitted changes")
+	}
+
+	if err := store.Commit(); err != nil {
+		t.Error(err)
+	}
+
+	if _, values, err := store.ProvePaths(paths[1:2], []any{new(noncommutative.String)}); err != nil || *values[0].(*noncommutative.String) != "updated" {
+		t.Error("Error: Should be provable after Commit", values, err)
+	}
+}
+
+func TestVerifyPathProofs(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	alice, bob := AliceAccount(), BobAccount()
+	prefix := "blcc://eth1.0/account/" + alice
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, prefix+"/balance", commutative.NewU256Delta(uint256.NewInt(100), true))
+	url0.Write(0, prefix+"/storage/native/elem-0", noncommutative.NewString("native"))
+	url0.Write(0, prefix+"/storage/ctrn-0/", commutative.NewPath())
+	url0.Write(0, prefix+"/storage/ctrn-0/elem-0", noncommutative.NewInt64(64))
+	commitTransitions(url, []uint32{0}, url0)
+
+	paths := []string{prefix + "/balance", prefix + "/storage/native/elem-0", prefix + "/storage/ctrn-0/elem-0", prefix + "/storage/ctrn-0/elem-1"}
+	result, _, err := store.ProvePaths(paths, []any{nil, nil, nil, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify the decoded JSON, as a light client would receive it.
+	buffer, _ := json.Marshal(result)
+	received := &storage.AccountResult{}
+	if err := json.Unmarshal(buffer, received); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := storage.VerifyAccountProof(store.Root(), received)
+	if err != nil || state.Balance.Uint64() != 100 {
+		t.Error("Error: Failed to verify the account", err)
+	}
+
+	values, err := storage.VerifyStorageProof(store.Root(), received)
+	if err != nil || values[0] == nil || values[3] != nil {
+		t.Fatal("Error: Failed to verify the storage", err)
+	}
+
+	if balance := new(commutative.U256).StorageDecode(values[0]).(*commutative.U256).Value().(uint256.Int); balance.Uint64() != 100 {
+		t.Error("Error: Wrong balance", balance)
+	}
+
+	if *new(noncommutative.String).StorageDecode(values[1]).(*noncommutative.String) != "native" ||
+		*new(noncommutative.Int64).StorageDecode(values[2]).(*noncommutative.Int64) != 64 {
+		t.Error("Error: Wrong values", values)
+	}
+
+	if _, err := storage.VerifyStorageProof(ethcommon.Hash{1}, received); err == nil {
+		t.Error("Error: Should fail with a wrong root")
+	}
+
+	received.Balance = (*hexutil.Big)(big.NewInt(101))
+	if _, err := storage.VerifyAccountProof(store.Root(), received); err == nil {
+		t.Error("Error: Should fail with a wrong balance")
+	}
+	received.Balance = result.Balance
+
+	received.StorageProof[2].Data = hexutil.Bytes(noncommutative.NewInt64(65).StorageEncode())
+	if _, err := storage.VerifyStorageProof(store.Root(), received); err == nil {
+		t.Error("Error: Should fail with wrong data")
+	}
+	received.StorageProof[2].Data = result.StorageProof[2].Data
+
+	received.StorageProof[2].Path = paths[3] // The key of another path
+	if _, err := storage.VerifyStorageProof(store.Root(), received); err == nil {
+		t.Error("Error: Should fail with a wrong path")
+	}
+	received.StorageProof[2].Path = paths[2]
+
+	// Only the balance, the nonce and the code can go without the storage keys
+	received.StorageProof[2].Key = ""
+	if _, err := storage.VerifyStorageProof(store.Root(), received); err == nil {
+		t.Error("Error: Should fail without the storage key")
+	}
+	received.StorageProof[2].Key = result.StorageProof[2].Key
+
+	received.StorageProof[0].Path = "blcc://eth1.0/account/" + bob + "/balance"
+	if _, err := storage.VerifyStorageProof(store.Root(), received); err == nil {
+		t.Error("Error: Should fail with a path of another account")
+	}
+	received.StorageProof[0].Path = paths[0]
+
+	// The proof of Alice can't be passed off as the one of Bob
+	received.Address = ethcommon.HexToAddress(bob)
+	if _, err := storage.VerifyAccountProof(store.Root(), received); err == nil {
+		t.Error("Error: Should fail with a forged account key")
+	}
+
+	received.AccountKey = nil
+	if _, err := storage.VerifyAccountProof(store.Root(), received); err == nil {
+		t.Error("Error: Should fail without the account key too")
+	}
+	received.Address, received.AccountKey = result.Address, result.AccountKey
+
+	if _, err := storage.VerifyStorageProof(store.Root(), received); err != nil {
+		t.Error("Error: Should be restored", err)
+	}
+
+	// The absence of an account is proven too.
+	result, _, _ = store.ProvePaths([]string{"blcc://eth1.0/account/" + bob + "/storage/native/elem-0"}, []any{nil})
+	if state, err := storage.VerifyAccountProof(store.Root(), result); err != nil || state.Root != types.EmptyRootHash {
+		t.Error("Error: Should prove the absence", err)
+	}
+
+	// A forged absence proof under the raw address, the account is saved under the hash of the hex address
+	address := ethcommon.HexToAddress(alice)
+	absence, _ := store.Prove(address)
+	nodes := make([]string, len(absence))
+	for i := range absence {
+		nodes[i] = hexutil.Encode(absence[i])
+	}
+
+	forged := &storage.AccountResult{
+		Address:      address,
+		AccountKey:   address[:],
+		AccountProof: nodes,
+		Balance:      (*hexutil.Big)(new(big.Int)),
+		CodeHash:     crypto.Keccak256Hash(nil),
+		StorageHash:  types.EmptyRootHash,
+	}
+
+	if _, err := storage.VerifyAccountProof(store.Root(), forged); err == nil {
+		t.Error("Error: Shouldn't accept the forged absence")
+	}
+
+	forged.AccountKey = nil
+	if _, err := storage.VerifyAccountProof(store.Root(), forged); err == nil {
+		t.Error("Error: Shouldn't accept the forged absence")
+	}
+}
+
+func TestVerifyGetProof(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	address := ethcommon.HexToAddress(AliceAccount())
+
+	// Saved under the raw address with the slots in hex, like the accounts from Ethereum
+	account := storage.NewAccount(string(address[:]), store.DiskDBs(), storage.EmptyAccountState())
+	account.Balance, account.Nonce = big.NewInt(7), 3
+	slots := []string{"0x01", "0x02", "0x03"}
+	for _, slot := range slots[:2] {
+		key := ethcommon.HexToHash(slot)
+		if err := account.Trie().Update(key[:], key[31:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	account.Root = account.Trie().Hash()
+	encoded := account.Encode()
+	if err := account.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Trie().Update(address[:], encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := store.GetProof(address, slots)
+	if err != nil || len(result.AccountKey) != 0 {
+		t.Fatal("Error: Failed to get the proof", err)
+	}
+
+	state, err := storage.VerifyAccountProof(store.Root(), result)
+	if err != nil || state.Nonce != 3 || state.Balance.Uint64() != 7 {
+		t.Error("Error: Failed to verify the account", err)
+	}
+
+	values, err := storage.VerifyStorageProof(store.Root(), result)
+	if err != nil || !bytes.Equal(values[0], []byte{1}) || !bytes.Equal(values[1], []byte{2}) || values[2] != nil {
+		t.Error("Error: Failed to verify the storage", values, err)
+	}
+
+	// Tampered
+	result.StorageProof[1].Value = (*hexutil.Big)(big.NewInt(3))
+	if _, err := storage.VerifyStorageProof(store.Root(), result); err == nil {
+		t.Error("Error: Should fail with a wrong value")
+	}
+	result.StorageProof[1].Value = (*hexutil.Big)(big.NewInt(2))
+
+	result.StorageProof[1].Proof = result.StorageProof[1].Proof[:len(result.StorageProof[1].Proof)-1]
+	if _, err := storage.VerifyStorageProof(store.Root(), result); err == nil {
+		t.Error("Error: Should fail with a truncated proof")
+	}
+
+	result.Nonce = 4
+	if _, err := storage.VerifyAccountProof(store.Root(), result); err == nil {
+		t.Error("Error: Should fail with a wrong nonce")
+	}
+	result.Nonce = 3
+
+	result.AccountKey = merkle.Sha256{}.Hash([]byte(AliceAccount())) // Not the key the proof is for
+	if _, err := storage.VerifyAccountProof(store.Root(), result); err == nil {
+		t.Error("Error: Should fail with a wrong account key")
+	}
+
+	// A missing account
+	result, err = store.GetProof(ethcommon.HexToAddress(BobAccount()), slots[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state, err := storage.VerifyAccountProof(store.Root(), result); err != nil || state.Root != types.EmptyRootHash {
+		t.Error("Error: Should prove the absence", err)
+	}
+}
+
+func TestEthDataStoreExportImport(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	alice, bob := AliceAccount(), BobAccount()
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, bob); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, root, commutative.NewPath())
+	for i := 0; i < 10; i++ {
+		url0.Write(0, root+"elem-"+fmt.Sprint(i), noncommutative.NewString(fmt.Sprint(i)))
+	}
+	url0.Write(0, "blcc://eth1.0/account/"+alice+"/balance", commutative.NewU256Delta(uint256.NewInt(100), true))
+	url0.Write(0, "blcc://eth1.0/account/"+bob+"/code", noncommutative.NewBytes([]byte{1, 2, 3}))
+	commitTransitions(url, []uint32{0}, url0)
+
+	keys, values := store.Dump()
+	elemIdx, _ := common.FindFirst(keys, root+"elem-9")
+	codeIdx, _ := common.FindFirst(keys, "blcc://eth1.0/account/"+bob+"/code")
+	if len(keys) != len(values) || elemIdx < 0 || codeIdx < 0 {
+		t.Error("Error: Wrong dump", len(keys))
+	}
+
+	if store.CheckSum() == [32]byte{} || store.CheckSum() != store.CheckSum() {
+		t.Error("Error: Wrong checksum")
+	}
+
+	file := t.TempDir() + "/state"
+	if err := store.ExportToFile(file); err != nil {
+		t.Error(err)
+	}
+
+	restored := storage.NewParallelEthMemDataStore()
+	if err := restored.ImportFromFile(file); err != nil {
+		t.Error(err)
+	}
+
+	if restored.Root() != store.Root() || restored.CheckSum() != store.CheckSum() {
+		t.Error("Error: The restored state is different")
+	}
+
+	if err := restored.ImportFromFile(file); err == nil {
+		t.Error("Error: Shouldn't import into a non-empty datastore")
+	}
+
+	url1 := ccurl.NewConcurrentUrl(restored)
+	if v, _ := url1.Read(1, root+"elem-3", new(noncommutative.String)); v == nil || v.(string) != "3" {
+		t.Error("Error: Wrong value", v)
+	}
+
+	if v, _ := url1.Read(1, "blcc://eth1.0/account/"+alice+"/balance", new(commutative.U256)); v == nil || v.(uint256.Int) != *uint256.NewInt(100) {
+		t.Error("Error: Wrong balance", v)
+	}
+
+	if v, _ := url1.Read(1, "blcc://eth1.0/account/"+bob+"/code", new(noncommutative.Bytes)); v == nil || !bytes.Equal(v.([]byte), []byte{1, 2, 3}) {
+		t.Error("Error: Wrong code", v)
+	}
+
+	if keys, _, _ := restored.Query(root, nil); len(keys) != 11 {
+		t.Error("Error: Wrong number of entries", len(keys))
+	}
+
+	// A corrupted snapshot
+	buffer, _ := os.ReadFile(file)
+	buffer[len(buffer)-1] ^= 0xff
+	if err := storage.NewParallelEthMemDataStore().Import(bytes.NewReader(buffer)); err == nil {
+		t.Error("Error: Should fail on a corrupted snapshot")
+	}
+
+	// A record claiming a huge length, only 6 bytes after the header
+	header := buffer[:4+ethcommon.HashLength]
+	if err := storage.NewParallelEthMemDataStore().Import(bytes.NewReader(append(common.Clone(header), 0xff, 0xff, 0xff, 0xff, 1, 2))); err != storage.ErrSnapshotRecordTooLarge {
+		t.Error("Error: Should reject the record", err)
+	}
+
+	// A record shorter than the length
+	if err := storage.NewParallelEthMemDataStore().Import(bytes.NewReader(append(common.Clone(header), 16, 0, 0, 0, 1, 2))); err == nil {
+		t.Error("Error: Should fail on a truncated record")
+	}
+
+	// A record with a malformed byteset header
+	record := []byte{0xff, 0xff, 0xff, 0x0f, 0, 0, 0, 0}
+	if err := storage.NewParallelEthMemDataStore().Import(bytes.NewReader(append(append(common.Clone(header), 8, 0, 0, 0), record...))); err == nil {
+		t.Error("Error: Should fail on a malformed record")
+	}
+
+	// An account with its storage paths malformed
+	state, _ := rlp.EncodeToBytes(&types.StateAccount{Balance: big.NewInt(0), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash[:]})
+	record = codec.Byteset{{1}, []byte(alice), state, {}, record, {}, {}}.Encode()
+	length := codec.Uint32(len(record)).Encode()
+	if err := storage.NewParallelEthMemDataStore().Import(bytes.NewReader(append(append(common.Clone(header), length...), record...))); err == nil {
+		t.Error("Error: Should fail on malformed account storage")
+	}
+}
+
+func TestEthDataStoreExportWithoutPreimages(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	alice, bob := AliceAccount(), BobAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, root, commutative.NewPath())
+	for i := 0; i < 10; i++ {
+		url0.Write(0, root+"elem-"+fmt.Sprint(i), noncommutative.NewString(fmt.Sprint(i)))
+	}
+	commitTransitions(url, []uint32{0}, url0)
+	dropPreimages(store)
+
+	// Bob is added afterwards, with the preimages
+	url1 := ccurl.NewConcurrentUrl(store)
+	if _, err := url1.NewAccount(ccurlcommon.SYSTEM, bob); err != nil {
+		t.Error(err)
+	}
+	url1.Write(ccurlcommon.SYSTEM, "blcc://eth1.0/account/"+bob+"/code", noncommutative.NewBytes([]byte{1, 2, 3}))
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url1)
+
+	// Alice's storage keys have no preimages
+	keys, values := store.Dump()
+	if codeIdx, _ := common.FindFirst(keys, "blcc://eth1.0/account/"+bob+"/code"); codeIdx < 0 || len(keys) != len(values) {
+		t.Error("Error: Wrong dump", keys)
+	}
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, root) {
+			t.Error("Error: Shouldn't be dumped", key)
+		}
+	}
+
+	exportImport := func() {
+		buffer := bytes.NewBuffer(nil)
+		if err := store.Export(buffer); err != nil {
+			t.Fatal(err)
+		}
+
+		restored := storage.NewParallelEthMemDataStore()
+		if err := restored.Import(bytes.NewReader(buffer.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		if restored.Root() != store.Root() || restored.CheckSum() != store.CheckSum() {
+			t.Error("Error: The restored state is different")
+		}
+
+		url2 := ccurl.NewConcurrentUrl(restored)
+		if v, _ := url2.Read(2, root+"elem-3", new(noncommutative.String)); v == nil || v.(string) != "3" {
+			t.Error("Error: Wrong value", v)
+		}
+
+		if v, _ := url2.Read(2, "blcc://eth1.0/account/"+bob+"/code", new(noncommutative.Bytes)); v == nil || !bytes.Equal(v.([]byte), []byte{1, 2, 3}) {
+			t.Error("Error: Wrong code", v)
+		}
+
+		// Still writable after the restore
+		url3 := ccurl.NewConcurrentUrl(restored)
+		url3.Write(3, root+"elem-10", noncommutative.NewString("10"))
+		commitTransitions(url2, []uint32{3}, url3)
+		if v, _ := ccurl.NewConcurrentUrl(restored).Read(4, root+"elem-10", new(noncommutative.String)); v == nil || v.(string) != "10" {
+			t.Error("Error: Wrong value", v)
+		}
+	}
+	exportImport()
+
+	// No preimages for the accounts either
+	dropPreimages(store)
+	if keys, _ := store.Dump(); len(keys) != 0 {
+		t.Error("Error: Should be empty", keys)
+	}
+	exportImport()
+}
+
+func TestEthDataStoreArchiveRead(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(0, root, commutative.NewPath())
+	url0.Write(0, root+"elem-0", noncommutative.NewString("0"))
+	commitTransitions(url, []uint32{0}, url0)
+	block1 := store.Root()
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	url1.Write(1, root+"elem-0", noncommutative.NewString("1"))
+	url1.Write(1, root+"elem-1", noncommutative.NewString("1"))
+	commitTransitions(url, []uint32{1}, url1)
+	block2 := store.Root()
+
+	archive := storage.NewArchive(store, 1)
+	past, release, err := ccurl.NewArchiveUrl(archive, block1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	if v, _ := past.Read(2, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "0" {
+		t.Error("Error: Wrong value at the past root", v)
+	}
+
+	if v, _ := past.Peek(root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "0" {
+		t.Error("Error: Wrong value at the past root", v)
+	}
+
+	if past.IfExists(root+"elem-1") || !past.IfExists(root+"elem-0") {
+		t.Error("Error: Wrong existence at the past root")
+	}
+
+	if v, _, _ := past.ReadAt(2, root, 0, new(noncommutative.String)); v == nil || v.(string) != "0" {
+		t.Error("Error: Wrong value at the past root", v)
+	}
+
+	latest, releaseLatest, _ := ccurl.NewArchiveUrl(archive, block2)
+	defer releaseLatest()
+	if v, _ := latest.Read(2, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "1" {
+		t.Error("Error: Wrong value at the latest root", v)
+	}
+
+	if v, _, _ := latest.ReadAt(2, root, 1, new(noncommutative.String)); v == nil || v.(string) != "1" {
+		t.Error("Error: Wrong value at the latest root", v)
+	}
+
+	// Only the latest root is kept
+	if archive.Len() != 1 {
+		t.Error("Error: Wrong archive size", archive.Len())
+	}
+
+	archived, _ := archive.At(block2)
+	defer archived.Release()
+	if err := archived.Commit(); err != storage.ErrReadonlyDataStore {
+		t.Error("Error: The archive should be readonly")
+	}
+
+	// Only the reads are exposed
+	if _, ok := interface{}(archived).(interface {
+		Prune([]ethcommon.Hash) (int, error)
+	}); ok {
+		t.Error("Error: The archive shouldn't be prunable")
+	}
+
+	if _, ok := interface{}(archived).(interfaces.ExpiryIndex); ok {
+		t.Error("Error: The archive shouldn't update the expiry index")
+	}
+
+	if _, err := archive.At([32]byte{1}); err == nil {
+		t.Error("Error: Shouldn't open an unknown root")
+	}
+}
+
+func TestEthDataStorePruning(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	store.EnablePruning(2)
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	url.Write(ccurlcommon.SYSTEM, root, commutative.NewPath())
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	roots := []ethcommon.Hash{store.Root()}
+	for i := 0; i < 5; i++ {
+		url0 := ccurl.NewConcurrentUrl(store)
+		url0.Write(1, root+"elem-"+fmt.Sprint(i), noncommutative.NewString(fmt.Sprint(i)))
+		url0.Write(1, root+"elem-0", noncommutative.NewString("v"+fmt.Sprint(i)))
+		commitTransitions(url, []uint32{1}, url0)
+		roots = append(roots, store.Root())
+	}
+
+	// Pruned at the 4th and the 6th roots
+	diskdb := store.DiskDBs()[0]
+	for i, root := range roots {
+		if ok, _ := diskdb.Has(root[:]); ok != (i >= len(roots)-2) {
+			t.Error("Error: Wrong root on disk", i, ok)
+		}
+	}
+
+	// The kept roots are complete on the disks
+	paraDB := ethmpt.NewParallelDatabase(store.DiskDBs(), nil)
+	for _, root := range roots[len(roots)-2:] {
+		worldTrie, err := ethmpt.New(ethmpt.TrieID(root), paraDB)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		iter := ethmpt.NewIterator(worldTrie.NodeIterator(nil))
+		for iter.Next() {
+			var state types.StateAccount
+			if err := rlp.DecodeBytes(iter.Value, &state); err != nil {
+				t.Error(err)
+			}
+
+			storageIter := ethmpt.NewIterator(storage.NewAccount("", store.DiskDBs(), state).Trie().NodeIterator(nil))
+			for storageIter.Next() {
+			}
+
+			if storageIter.Err != nil {
+				t.Error("Error: Missing storage nodes", storageIter.Err)
+			}
+		}
+
+		if iter.Err != nil {
+			t.Error("Error: Missing world trie nodes", iter.Err)
+		}
+	}
+
+	if deleted, err := store.Prune(roots[len(roots)-2:]); err != nil || deleted != 0 {
+		t.Error("Error: Nothing should be left to prune", deleted, err)
+	}
+
+	if v, _ := ccurl.NewConcurrentUrl(store).Read(2, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "v4" {
+		t.Error("Error: Wrong value after pruning", v)
+	}
+
+	if keys, _, _ := store.Query(root, nil); len(keys) != 6 {
+		t.Error("Error: Wrong number of entries after pruning", len(keys))
+	}
+}
+
+func TestEthDataStorePruningWithArchive(t *testing.T) {
+	store := storage.NewParallelEthMemDataStore()
+	store.EnablePruning(1)
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	url.Write(ccurlcommon.SYSTEM, root, commutative.NewPath())
+	url.Write(ccurlcommon.SYSTEM, root+"elem-0", noncommutative.NewString("v"))
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+	block := store.Root()
+
+	archive := storage.NewArchive(store, 0)
+	if view, err := archive.At(block); err != nil {
+		t.Fatal(err)
+	} else {
+		view.Release()
+	}
+
+	version := 0
+	write := func(n int) {
+		for i := 0; i < n; i++ {
+			version++
+			url0 := ccurl.NewConcurrentUrl(store)
+			url0.Write(1, root+"elem-0", noncommutative.NewString("v"+fmt.Sprint(version)))
+			commitTransitions(url, []uint32{1}, url0)
+		}
+	}
+
+	// Pruned a few times, the archived root is still complete
+	write(4)
+	if ok, _ := store.DiskDBs()[0].Has(block[:]); !ok {
+		t.Error("Error: The archived root shouldn't be pruned")
+	}
+
+	past, release, err := ccurl.NewArchiveUrl(archive, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := past.Read(2, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "v" {
+		t.Error("Error: Wrong value at the archived root", v)
+	}
+	release()
+
+	// Released after closing
+	archive.Close()
+	if archive.Len() != 0 {
+		t.Error("Error: Should be empty", archive.Len())
+	}
+
+	write(2)
+	if ok, _ := store.DiskDBs()[0].Has(block[:]); ok {
+		t.Error("Error: Should be pruned after the archive is closed")
+	}
+
+	// Released after eviction too
+	recent := storage.NewArchive(store, 1)
+	block = store.Root()
+	view, _ := recent.At(block)
+	view.Release()
+	write(1)
+	latest, _ := recent.At(store.Root())
+	latest.Release()
+	write(2)
+	if ok, _ := store.DiskDBs()[0].Has(block[:]); ok {
+		t.Error("Error: Should be pruned after eviction")
+	}
+
+	// A view still in use is kept after eviction, until released
+	block = store.Root()
+	view, _ = recent.At(block)
+	write(1)
+	latest, _ = recent.At(store.Root())
+	latest.Release()
+	write(2)
+	if v, _ := ccurl.NewConcurrentUrl(view).Read(2, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "v"+fmt.Sprint(version-3) {
+		t.Error("Error: The evicted view should still be readable", v)
+	}
+
+	view.Release()
+	view.Release() // Only the first one counts
+	write(2)
+	if ok, _ := store.DiskDBs()[0].Has(block[:]); ok {
+		t.Error("Error: Should be pruned after the view is released")
+	}
+}
+
 func BenchmarkMultipleAccountCommitDataStore(b *testing.B) {
 	// store := chooseDataStore() // Eth data store
 	store := cachedstorage.NewDataStore(nil, nil, nil, storage.Codec{}.Encode, storage.Codec{}.Decode) // Native data store
@@ -467,6 +1438,231 @@ func TestLevelDBBasic(t *testing.T) {
 	}
 }
 
+func TestPebbleDataStore(t *testing.T) {
+	dir := t.TempDir()
+	options := storage.DefaultPebbleOptions()
+	options.Cache, options.Handles = 16, 32
+
+	pebbleStore, err := storage.NewPebbleDataStore(dir+"/pebble", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levelStore := storage.NewLevelDBDataStore(dir + "/leveldb")
+	if levelStore == nil {
+		t.Fatal("Error: Failed to open the LevelDB datastore")
+	}
+
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+	for _, store := range []*storage.EthDataStore{pebbleStore, levelStore} {
+		url := ccurl.NewConcurrentUrl(store)
+		if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+			t.Error(err)
+		}
+		url.Write(ccurlcommon.SYSTEM, root, commutative.NewPath())
+		url.Write(ccurlcommon.SYSTEM, root+"elem-0", noncommutative.NewString("0"))
+		commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+	}
+
+	if pebbleStore.Root() != levelStore.Root() {
+		t.Error("Error: Different roots", pebbleStore.Root(), levelStore.Root())
+	}
+
+	// The same proofs from both backends
+	proofs := [2][][]byte{}
+	for i, store := range []*storage.EthDataStore{pebbleStore, levelStore} {
+		accountKey := merkle.Sha256{}.Hash([]byte(alice))
+		proofDB := memorydb.New()
+		if err := store.Trie().Prove(accountKey, 0, proofDB); err != nil {
+			t.Error(err)
+		}
+
+		if _, err := ethmpt.VerifyProof(store.Root(), accountKey, proofDB); err != nil {
+			t.Error(err)
+		}
+
+		account, _ := store.GetAccount(alice, &ethmpt.AccessListCache{})
+		proofs[i], err = account.Prove([32]byte(account.Hash([]byte(root + "elem-0"))))
+		if err != nil || len(proofs[i]) == 0 {
+			t.Error("Error: Failed to prove", err)
+		}
+	}
+
+	if !reflect.DeepEqual(proofs[0], proofs[1]) {
+		t.Error("Error: Different storage proofs")
+	}
+
+	// Reload from the disks
+	committed := pebbleStore.Root()
+	pebbleStore.Close()
+	levelStore.Close()
+
+	if pebbleStore, err = storage.NewPebbleDataStore(dir+"/pebble", options); err != nil {
+		t.Fatal(err)
+	}
+	defer pebbleStore.Close()
+
+	// Not reloaded by the old constructor
+	if levelStore = storage.NewLevelDBDataStore(dir + "/leveldb"); levelStore == nil || levelStore.Root() != [32]byte{} {
+		t.Fatal("Error: Should start with an empty world state")
+	}
+	levelStore.Close()
+
+	if levelStore, err = storage.OpenLevelDBDataStore(dir + "/leveldb"); err != nil {
+		t.Fatal("Error: Failed to reopen the LevelDB datastore", err)
+	}
+	defer levelStore.Close()
+
+	for _, store := range []*storage.EthDataStore{pebbleStore, levelStore} {
+		if store.Root() != committed {
+			t.Error("Error: Wrong root after reload", store.Root())
+		}
+
+		if v, _ := ccurl.NewConcurrentUrl(store).Read(1, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "0" {
+			t.Error("Error: Wrong value after reload", v)
+		}
+
+		url := ccurl.NewConcurrentUrl(store)
+		url.Write(1, root+"elem-1", noncommutative.NewString("1"))
+		commitTransitions(url, []uint32{1}, url)
+	}
+
+	if pebbleStore.Root() != levelStore.Root() || pebbleStore.Root() == committed {
+		t.Error("Error: Different roots after reload")
+	}
+}
+
+func TestShardedDataStoreMigration(t *testing.T) {
+	dir := t.TempDir()
+	single := storage.NewLevelDBDataStore(dir + "/single")
+	if single == nil {
+		t.Fatal("Error: Failed to open the LevelDB datastore")
+	}
+	defer single.Close()
+
+	alice, bob := AliceAccount(), BobAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(single)
+	url.NewAccount(ccurlcommon.SYSTEM, alice)
+	url.NewAccount(ccurlcommon.SYSTEM, bob)
+	url.Write(ccurlcommon.SYSTEM, root, commutative.NewPath())
+	for i := 0; i < 50; i++ {
+		url.Write(ccurlcommon.SYSTEM, root+"elem-"+fmt.Sprint(i), noncommutative.NewString(fmt.Sprint(i)))
+	}
+	url.Write(ccurlcommon.SYSTEM, "blcc://eth1.0/account/"+bob+"/code", noncommutative.NewBytes([]byte{1, 2, 3}))
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	sharded, err := storage.MigrateToSharded(single, dir+"/sharded")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sharded.Root() != single.Root() || sharded.CheckSum() != single.CheckSum() {
+		t.Error("Error: The migrated state is different")
+	}
+
+	// The data is spread across the shards
+	used := 0
+	for _, diskdb := range sharded.DiskDBs() {
+		iter := diskdb.NewIterator(nil, nil)
+		if iter.Next() {
+			used++
+		}
+		iter.Release()
+	}
+
+	if used < 2 {
+		t.Error("Error: The data should be in different shards", used)
+	}
+
+	// Reload the sharded datastore
+	sharded.Close()
+	if sharded, err = storage.NewShardedLevelDBDataStore(dir + "/sharded"); err != nil {
+		t.Fatal(err)
+	}
+	defer sharded.Close()
+
+	if v, _ := ccurl.NewConcurrentUrl(sharded).Read(1, root+"elem-7", new(noncommutative.String)); v == nil || v.(string) != "7" {
+		t.Error("Error: Wrong value after migration", v)
+	}
+
+	if v, _ := ccurl.NewConcurrentUrl(sharded).Read(1, "blcc://eth1.0/account/"+bob+"/code", new(noncommutative.Bytes)); v == nil || !bytes.Equal(v.([]byte), []byte{1, 2, 3}) {
+		t.Error("Error: Wrong code after migration", v)
+	}
+
+	// The same updates lead to the same root
+	for _, store := range []*storage.EthDataStore{single, sharded} {
+		url := ccurl.NewConcurrentUrl(store)
+		url.Write(1, root+"elem-0", noncommutative.NewString("updated"))
+		url.Write(1, root+"elem-50", noncommutative.NewString("50"))
+		commitTransitions(url, []uint32{1}, url)
+	}
+
+	if sharded.Root() != single.Root() {
+		t.Error("Error: Different roots after the same updates")
+	}
+}
+
+func TestShardedDataStoreMigrationWithoutPreimages(t *testing.T) {
+	dir := t.TempDir()
+	single := storage.NewLevelDBDataStore(dir + "/single")
+	if single == nil {
+		t.Fatal("Error: Failed to open the LevelDB datastore")
+	}
+
+	alice, bob := AliceAccount(), BobAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(single)
+	url.NewAccount(ccurlcommon.SYSTEM, alice)
+	url.NewAccount(ccurlcommon.SYSTEM, bob)
+	url.Write(ccurlcommon.SYSTEM, root, commutative.NewPath())
+	url.Write(ccurlcommon.SYSTEM, root+"elem-0", noncommutative.NewString("0"))
+	url.Write(ccurlcommon.SYSTEM, "blcc://eth1.0/account/"+bob+"/code", noncommutative.NewBytes([]byte{1, 2, 3}))
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	// Reopened without the cached accounts, only the entries committed after have preimages
+	dropPreimages(single)
+	single.Close()
+	single, err := storage.OpenLevelDBDataStore(dir + "/single")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer single.Close()
+
+	url = ccurl.NewConcurrentUrl(single)
+	url.Write(1, root+"elem-1", noncommutative.NewString("1"))
+	commitTransitions(url, []uint32{1}, url)
+
+	sharded, err := storage.MigrateToSharded(single, dir+"/sharded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sharded.Close()
+
+	if sharded.Root() != single.Root() {
+		t.Error("Error: The migrated state is different")
+	}
+
+	for i, v := range []string{"0", "1"} {
+		if value, _ := ccurl.NewConcurrentUrl(sharded).Read(2, root+"elem-"+fmt.Sprint(i), new(noncommutative.String)); value == nil || value.(string) != v {
+			t.Error("Error: Wrong value after migration", value)
+		}
+	}
+
+	if v, _ := ccurl.NewConcurrentUrl(sharded).Read(2, "blcc://eth1.0/account/"+bob+"/code", new(noncommutative.Bytes)); v == nil || !bytes.Equal(v.([]byte), []byte{1, 2, 3}) {
+		t.Error("Error: Wrong code after migration", v)
+	}
+
+	// Only the preimages left are carried over
+	keys, _ := single.Dump()
+	if migrated, _ := sharded.Dump(); !slices.Contains(migrated, root+"elem-1") || slices.Contains(migrated, "blcc://eth1.0/account/"+bob+"/code") || !slices.Equal(migrated, keys) {
+		t.Error("Error: Wrong preimages after migration", migrated)
+	}
+}
+
 func BenchmarkLevelDBPerformance1M(t *testing.B) {
 	leveldb, err := rawdb.NewLevelDBDatabase("./leveldb", 0, 16, "temp", false)
 	if err != nil {
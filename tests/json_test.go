@@ -0,0 +1,107 @@
+package ccurltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/arcology-network/common-lib/common"
+	ccurl "github.com/arcology-network/concurrenturl"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	indexer "github.com/arcology-network/concurrenturl/indexer"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+	storage "github.com/arcology-network/concurrenturl/storage"
+)
+
+func TestTypesJSON(t *testing.T) {
+	sorted := commutative.NewSortedPath().(*commutative.Path)
+	sorted.SetDelta(commutative.NewPathDelta([]string{"b", "a"}, []string{"c"}))
+
+	values := []interfaces.Type{
+		commutative.InitNewPaths([]string{"elem-0", "elem-1"}),
+		sorted,
+		commutative.NewBoundedUint64(1, 100),
+		commutative.NewSignedUint64Delta(5, false).(interfaces.Type),
+		commutative.NewInt64(-5, 5).(interfaces.Type),
+		commutative.NewBoundedU256FromU64(1, 1000),
+		noncommutative.NewInt64(-7),
+		noncommutative.NewString("alice"),
+		noncommutative.NewBigint(-123456789).(interfaces.Type),
+		noncommutative.NewBytes([]byte{1, 2, 3}),
+		noncommutative.NewStruct([]byte{1}, []byte{2, 3}),
+		noncommutative.NewExpiring(noncommutative.NewString("session"), 10),
+	}
+
+	for _, in := range values {
+		buffer, err := json.Marshal(in)
+		if err != nil {
+			t.Error(err)
+		}
+
+		out := storage.NewType(in.TypeID())
+		if err := json.Unmarshal(buffer, out); err != nil || !in.Equal(out) {
+			t.Error("Error: Mismatch", string(buffer), err)
+		}
+
+		if again, _ := json.Marshal(out); !bytes.Equal(buffer, again) {
+			t.Error("Error: Mismatch", string(buffer), string(again))
+		}
+	}
+}
+
+func TestTransitionsJSON(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	url1.Write(1, root, commutative.NewPath())
+	url1.Write(1, root+"elem-0", noncommutative.NewString("0"))
+	url1.Write(1, root+"elem-1", commutative.NewBoundedUint64(0, 100))
+	url1.Write(1, root+"elem-1", commutative.NewUint64Delta(10))
+	url1.Read(1, root+"elem-0", new(noncommutative.String))
+
+	accesses := indexer.Univalues(common.Clone(url1.Export(indexer.Sorter))).To(indexer.ITCAccess{})
+	transitions := indexer.Univalues(common.Clone(url1.Export(indexer.Sorter))).To(indexer.ITCTransition{})
+
+	for _, in := range []indexer.Univalues{accesses, transitions} {
+		buffer, err := json.Marshal(in)
+		if err != nil {
+			t.Error(err)
+		}
+
+		// The transient states like the touched flags aren't encoded in either format.
+		decoded := indexer.Univalues{}.Decode(in.Encode()).(indexer.Univalues)
+
+		var out indexer.Univalues
+		if err := json.Unmarshal(buffer, &out); err != nil || len(out) != len(in) || !decoded.Equal(out) {
+			t.Error("Error: Mismatch", err)
+		}
+
+		if again, _ := json.Marshal(out); !bytes.Equal(buffer, again) {
+			t.Error("Error: Mismatch", string(buffer), string(again))
+		}
+	}
+
+	// Replay the decoded transitions
+	buffer, _ := json.Marshal(transitions)
+	var replay indexer.Univalues
+	json.Unmarshal(buffer, &replay)
+	url.Import(replay).Sort().Commit([]uint32{1})
+
+	if v, _ := ccurl.NewConcurrentUrl(store).Read(2, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "0" {
+		t.Error("Error: Wrong value", v)
+	}
+
+	if v, _ := ccurl.NewConcurrentUrl(store).Read(2, root+"elem-1", new(commutative.Uint64)); v == nil || v.(uint64) != 10 {
+		t.Error("Error: Wrong value", v)
+	}
+}
@@ -0,0 +1,140 @@
+package ccurltest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arcology-network/common-lib/common"
+	orderedset "github.com/arcology-network/common-lib/container/set"
+	ccurl "github.com/arcology-network/concurrenturl"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	indexer "github.com/arcology-network/concurrenturl/indexer"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+)
+
+func commitTransitions(url *ccurl.ConcurrentUrl, txs []uint32, urls ...*ccurl.ConcurrentUrl) {
+	for _, v := range urls {
+		trans := indexer.Univalues(common.Clone(v.Export(indexer.Sorter))).To(indexer.ITCTransition{})
+		url.Import(indexer.Univalues{}.Decode(indexer.Univalues(trans).Encode()).(indexer.Univalues))
+	}
+	url.Sort()
+	url.Commit(txs)
+}
+
+func TestNestedContainers(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	if _, err := url1.Write(1, root+"sub/", commutative.NewPath()); err == nil {
+		t.Error("Error: The parent path doesn't exist yet")
+	}
+
+	for _, path := range []string{root, root + "sub/", root + "sub/sub2/"} {
+		if _, err := url1.Write(1, path, commutative.NewPath()); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := url1.Write(1, root+"sub/elem-0", noncommutative.NewString("0")); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := url1.Write(1, root+"sub/sub2/elem-1", noncommutative.NewString("1")); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{1}, url1)
+
+	// Add to the containers at different levels
+	url2 := ccurl.NewConcurrentUrl(store)
+	if _, err := url2.Write(2, root+"sub/sub2/elem-2", noncommutative.NewString("2")); err != nil {
+		t.Error(err)
+	}
+
+	url3 := ccurl.NewConcurrentUrl(store)
+	if _, err := url3.Write(3, root+"sub/sub3/", commutative.NewPath()); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{2, 3}, url2, url3)
+
+	url = ccurl.NewConcurrentUrl(store)
+	expected := map[string][]string{
+		root:               {"sub/"},
+		root + "sub/":      {"sub2/", "elem-0", "sub3/"},
+		root + "sub/sub2/": {"elem-1", "elem-2"},
+		root + "sub/sub3/": {},
+	}
+
+	for path, keys := range expected {
+		if v, _ := url.Read(4, path, new(commutative.Path)); v == nil || !reflect.DeepEqual(v.(*orderedset.OrderedSet).Keys(), keys) {
+			t.Error("Error: Wrong keys", path, v)
+		}
+	}
+
+	// Delete a container in the middle, the parent should be updated
+	url4 := ccurl.NewConcurrentUrl(store)
+	if _, err := url4.Write(4, root+"sub/sub2/", nil); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{4}, url4)
+
+	url = ccurl.NewConcurrentUrl(store)
+	if v, _ := url.Read(5, root+"sub/", new(commutative.Path)); v == nil || !reflect.DeepEqual(v.(*orderedset.OrderedSet).Keys(), []string{"elem-0", "sub3/"}) {
+		t.Error("Error: Wrong keys", v)
+	}
+
+	for _, path := range []string{root + "sub/sub2/", root + "sub/sub2/elem-1", root + "sub/sub2/elem-2"} {
+		if v, _ := url.Read(5, path, new(noncommutative.String)); v != nil {
+			t.Error("Error: Should have been deleted", path)
+		}
+	}
+
+	// Delete the root container, everything underneath should be gone
+	url5 := ccurl.NewConcurrentUrl(store)
+	if _, err := url5.Write(5, root, nil); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{5}, url5)
+
+	url = ccurl.NewConcurrentUrl(store)
+	for _, path := range []string{root, root + "sub/", root + "sub/elem-0", root + "sub/sub3/"} {
+		if url.IfExists(path) {
+			t.Error("Error: Should have been deleted", path)
+		}
+	}
+}
+
+func TestNestedContainerMaxDepth(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+
+	path := "blcc://eth1.0/account/" + alice + "/storage/"
+	for ccurlcommon.PathDepth(path) < ccurlcommon.MAX_DEPTH {
+		path += "ctrn/"
+		if _, err := url.Write(1, path, commutative.NewPath()); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := url.Write(1, path+"elem", noncommutative.NewString("too deep")); err == nil || !strings.Contains(err.Error(), "max depth") {
+		t.Error("Error: Should have exceeded the max depth", err)
+	}
+
+	if _, err := url.Write(1, path+"ctrn/", commutative.NewPath()); err == nil {
+		t.Error("Error: Should have exceeded the max depth")
+	}
+}
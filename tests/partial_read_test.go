@@ -0,0 +1,103 @@
+package ccurltest
+
+import (
+	"testing"
+
+	"github.com/arcology-network/common-lib/common"
+	ccurl "github.com/arcology-network/concurrenturl"
+	arbitrator "github.com/arcology-network/concurrenturl/arbitrator"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	indexer "github.com/arcology-network/concurrenturl/indexer"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+)
+
+func accessRecords(url *ccurl.ConcurrentUrl) []interfaces.Univalue {
+	accesses := indexer.Univalues(common.Clone(url.Export(indexer.Sorter))).To(indexer.ITCAccess{})
+	return indexer.Univalues{}.Decode(indexer.Univalues(accesses).Encode()).(indexer.Univalues)
+}
+
+func detectConflicts(lhv, rhv []interfaces.Univalue) int {
+	IDVec := append(common.Fill(make([]uint32, len(lhv)), 0), common.Fill(make([]uint32, len(rhv)), 1)...)
+	return len((&arbitrator.Arbitrator{}).Detect(IDVec, append(common.Clone(lhv), common.Clone(rhv)...)))
+}
+
+func TestLengthAndExistenceReads(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store)
+	url0.Write(1, root, commutative.NewPath())
+	url0.Write(1, root+"elem-0", noncommutative.NewString("0"))
+	url0.Write(1, root+"elem-1", noncommutative.NewString("1"))
+	commitTransitions(url, []uint32{1}, url0)
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	if length, _ := url1.Length(2, root); length != 2 {
+		t.Error("Error: Wrong length", length)
+	}
+
+	url2 := ccurl.NewConcurrentUrl(store)
+	if exists, _ := url2.HasKey(3, root, "elem-1"); !exists {
+		t.Error("Error: elem-1 should exist")
+	}
+
+	if exists, _ := url2.HasKey(3, root, "elem-9"); exists {
+		t.Error("Error: elem-9 shouldn't exist")
+	}
+
+	url3 := ccurl.NewConcurrentUrl(store) // Add a new element
+	url3.Write(4, root+"elem-2", noncommutative.NewString("2"))
+
+	url4 := ccurl.NewConcurrentUrl(store) // Update an existing element
+	url4.Write(5, root+"elem-0", noncommutative.NewString("00"))
+
+	url5 := ccurl.NewConcurrentUrl(store) // Remove an element
+	url5.Write(6, root+"elem-1", nil)
+
+	url6 := ccurl.NewConcurrentUrl(store) // Full read
+	url6.Read(7, root, new(commutative.Path))
+
+	lengthReads, existReads, fullReads := accessRecords(url1), accessRecords(url2), accessRecords(url6)
+	insertion, update, deletion := accessRecords(url3), accessRecords(url4), accessRecords(url5)
+
+	if pos, v := common.FindFirstIf(lengthReads, func(v interfaces.Univalue) bool { return *v.GetPath() == root }); pos < 0 || !(*v).IsPartialRead() || (*v).LengthReads() != 1 {
+		t.Error("Error: The length read should be recorded")
+	}
+
+	if pos, v := common.FindFirstIf(existReads, func(v interfaces.Univalue) bool { return *v.GetPath() == root }); pos < 0 || !common.EqualArray((*v).KeyReads(), []string{"elem-1", "elem-9"}) {
+		t.Error("Error: The existence reads should be recorded")
+	}
+
+	if n := detectConflicts(lengthReads, update); n != 0 {
+		t.Error("Error: The length is unchanged", n)
+	}
+
+	if n := detectConflicts(existReads, insertion); n != 0 {
+		t.Error("Error: elem-2 wasn't checked", n)
+	}
+
+	if n := detectConflicts(lengthReads, insertion); n != 1 {
+		t.Error("Error: The length has changed", n)
+	}
+
+	if n := detectConflicts(lengthReads, deletion); n != 1 {
+		t.Error("Error: The length has changed", n)
+	}
+
+	if n := detectConflicts(existReads, deletion); n != 1 {
+		t.Error("Error: elem-1 has been removed", n)
+	}
+
+	if n := detectConflicts(fullReads, insertion); n != 1 {
+		t.Error("Error: A full read conflicts with any insertion", n)
+	}
+}
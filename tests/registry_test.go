@@ -0,0 +1,79 @@
+package ccurltest
+
+import (
+	"bytes"
+	"testing"
+
+	codec "github.com/arcology-network/common-lib/codec"
+	ccurl "github.com/arcology-network/concurrenturl"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+	storage "github.com/arcology-network/concurrenturl/storage"
+	univalue "github.com/arcology-network/concurrenturl/univalue"
+)
+
+const CUSTOM_BYTES uint8 = 200
+
+// An application defined type, reusing the bytes implementation.
+type customBytes struct {
+	*noncommutative.Bytes
+}
+
+func newCustomBytes(v []byte) interfaces.Type {
+	return &customBytes{noncommutative.NewBytes(v).(*noncommutative.Bytes)}
+}
+
+func (this *customBytes) TypeID() uint8 { return CUSTOM_BYTES }
+
+func init() {
+	storage.RegisterType(
+		CUSTOM_BYTES,
+		func() interfaces.Type { return newCustomBytes([]byte{}) },
+		func(buffer []byte) interface{} {
+			return &customBytes{(&noncommutative.Bytes{}).Decode(buffer).(*noncommutative.Bytes)}
+		},
+		func(buffer []byte) interface{} {
+			return &customBytes{(&noncommutative.Bytes{}).StorageDecode(buffer).(*noncommutative.Bytes)}
+		},
+	)
+}
+
+func TestTypeRegistry(t *testing.T) {
+	if err := storage.RegisterType(commutative.PATH, commutative.NewPath, commutative.NewPath().Decode, commutative.NewPath().StorageDecode); err == nil {
+		t.Error("Error: The built-in type IDs should be reserved")
+	}
+
+	if storage.NewType(noncommutative.STRUCT).TypeID() != noncommutative.STRUCT || storage.NewType(199) != nil {
+		t.Error("Error: Wrong type created")
+	}
+
+	in := univalue.NewUnivalue(1, "blcc://eth1.0/account/"+AliceAccount()+"/storage/ctrn-0/elem-0", 0, 1, 0, newCustomBytes([]byte{1, 2, 3}), nil)
+	out := (&univalue.Univalue{}).Decode(in.Encode()).(*univalue.Univalue)
+	if v, ok := out.Value().(*customBytes); !ok || !bytes.Equal(v.Value().(codec.Bytes), []byte{1, 2, 3}) {
+		t.Error("Error: Failed to decode the custom type", out.Value())
+	}
+
+	v := storage.Rlp{}.Decode(newCustomBytes([]byte{4, 5}).StorageEncode(), CUSTOM_BYTES)
+	if v == nil || v.(*customBytes).TypeID() != CUSTOM_BYTES {
+		t.Error("Error: Failed to storage decode the custom type")
+	}
+}
+
+func TestNewAccountFromRegistry(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+
+	url := ccurl.NewConcurrentUrl(store)
+	trans, err := url.NewAccount(ccurlcommon.SYSTEM, alice)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, v := range trans {
+		if v.Value() == nil || v.Value().(interfaces.Type).TypeID() != v.TypeID() {
+			t.Error("Error: Failed to create the built-in value", *v.GetPath())
+		}
+	}
+}
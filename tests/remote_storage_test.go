@@ -0,0 +1,196 @@
+package ccurltest
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	codec "github.com/arcology-network/common-lib/codec"
+	ccurl "github.com/arcology-network/concurrenturl"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+	storage "github.com/arcology-network/concurrenturl/storage"
+	univalue "github.com/arcology-network/concurrenturl/univalue"
+)
+
+func TestRemoteDataStore(t *testing.T) {
+	local := storage.NewParallelEthMemDataStore()
+	server := storage.NewRemoteServer(local, func(buffer []byte) interface{} {
+		return new(univalue.Univalue).Decode(buffer)
+	})
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	store := storage.NewRemoteDataStore(httpServer.URL, time.Second)
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	url1.Write(1, root, commutative.NewPath())
+	url1.Write(1, root+"elem-0", noncommutative.NewString("0"))
+	url1.Write(1, root+"elem-1", noncommutative.NewInt64(1))
+	commitTransitions(url, []uint32{1}, url1)
+
+	if err := store.Err(); err != nil {
+		t.Error(err)
+	}
+
+	if local.Root() == [32]byte{} {
+		t.Error("Error: Should be committed to the local store")
+	}
+
+	// Read through the remote store
+	if v, _ := ccurl.NewConcurrentUrl(store).Read(2, root+"elem-0", new(noncommutative.String)); v == nil || v.(string) != "0" {
+		t.Error("Error: Wrong value", v)
+	}
+
+	if v, _ := ccurl.NewConcurrentUrl(store).Read(2, root+"elem-1", new(noncommutative.Int64)); v == nil || v.(int64) != 1 {
+		t.Error("Error: Wrong value", v)
+	}
+
+	if v, err := store.Retrive(root+"elem-0", new(noncommutative.String)); err != nil || !v.(*noncommutative.String).Equal(noncommutative.NewString("0")) {
+		t.Error("Error: Wrong value", v, err)
+	}
+
+	values := store.BatchRetrive([]string{root + "elem-0", root + "elem-9"}, []any{new(noncommutative.String), new(noncommutative.String)})
+	if len(values) != 2 || values[0] == nil || values[1] != nil {
+		t.Error("Error: Wrong values", values)
+	}
+
+	if !store.IfExists(root+"elem-0") || store.IfExists(root+"elem-9") {
+		t.Error("Error: Wrong existence")
+	}
+
+	// Query through the remote store
+	localKeys, localValues, err := local.Query(root, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	keys, encoded, err := store.Query(root, nil)
+	if err != nil || !reflect.DeepEqual(keys, localKeys) || !reflect.DeepEqual(encoded, localValues) {
+		t.Error("Error: Mismatch", keys, localKeys, err)
+	}
+
+	if !slices.Contains(keys, root+"elem-0") || !slices.Contains(keys, root+"elem-1") {
+		t.Error("Error: Missing keys", keys)
+	}
+
+	keys, _, err = store.Query(root, func(_, key string) bool { return strings.HasSuffix(key, "elem-1") })
+	if err != nil || len(keys) != 1 || keys[0] != root+"elem-1" {
+		t.Error("Error: Should be filtered", keys, err)
+	}
+
+	if _, err := store.Retrive(root+"elem-0", nil); err == nil {
+		t.Error("Error: The type is needed")
+	}
+
+	// Dump and checksum of the served store
+	localKeys, localDumped := local.Dump()
+	if keys, dumped := store.Dump(); !reflect.DeepEqual(keys, localKeys) || !reflect.DeepEqual(dumped, localDumped) {
+		t.Error("Error: Dump mismatch", keys, localKeys)
+	}
+
+	if store.CheckSum() != local.CheckSum() || store.CheckSum() == [32]byte{} {
+		t.Error("Error: Checksum mismatch")
+	}
+
+	// Malformed requests
+	for _, op := range []string{storage.REMOTE_INJECT, storage.REMOTE_COMMIT, storage.REMOTE_RETRIVE, storage.REMOTE_QUERY} {
+		for _, body := range [][]byte{
+			{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0},
+			codec.Byteset{{0xff, 0xff, 0, 0, 0, 0, 0, 0}, {}}.Encode(),
+			{1, 0},
+		} {
+			resp, err := http.Post(httpServer.URL+"/"+op, "application/octet-stream", bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				t.Error("Error: Should reject the request", op, body)
+			}
+		}
+	}
+
+	limit := storage.MAX_REQUEST_SIZE
+	storage.MAX_REQUEST_SIZE = 16
+	if _, _, err := store.Query(root, nil); !errors.Is(err, storage.ErrRemoteFailed) {
+		t.Error("Error: Should be too large", err)
+	}
+	storage.MAX_REQUEST_SIZE = limit
+
+	if _, _, err := store.Query(root, nil); err != nil {
+		t.Error("Error: The server should still work", err)
+	}
+
+	// Network failures
+	httpServer.Close()
+	if _, err := store.Retrive(root+"elem-0", new(noncommutative.String)); !errors.Is(err, storage.ErrRemoteUnreachable) {
+		t.Error("Error: Should be unreachable", err)
+	}
+
+	var remoteErr *storage.RemoteError
+	store.Precommit([]string{}, []interface{}{})
+	if err := store.Commit(); !errors.As(err, &remoteErr) || remoteErr.Op != storage.REMOTE_COMMIT {
+		t.Error("Error: Should be a remote error", err)
+	}
+
+	if err := store.Commit(); err != nil {
+		t.Error("Error: The error should only be reported once", err)
+	}
+
+	if store.IfExists(root+"elem-0") || !errors.Is(store.Err(), storage.ErrRemoteUnreachable) {
+		t.Error("Error: The error should be recorded", store.Err())
+	}
+}
+
+// The commits of the clients are applied one by one, each one in a single request.
+func TestRemoteConcurrentCommits(t *testing.T) {
+	local := storage.NewParallelEthMemDataStore()
+	server := storage.NewRemoteServer(local, func(buffer []byte) interface{} {
+		return new(univalue.Univalue).Decode(buffer)
+	})
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	accounts := []string{AliceAccount(), BobAccount()}
+	var wg sync.WaitGroup
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(account string) {
+			defer wg.Done()
+			store := storage.NewRemoteDataStore(httpServer.URL, time.Second)
+			url := ccurl.NewConcurrentUrl(store)
+			url.NewAccount(ccurlcommon.SYSTEM, account)
+			url.Write(ccurlcommon.SYSTEM, "blcc://eth1.0/account/"+account+"/storage/ctrn-0/", commutative.NewPath())
+			commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+			if err := store.Err(); err != nil {
+				t.Error(err)
+			}
+		}(account)
+	}
+	wg.Wait()
+
+	for _, account := range accounts {
+		if !local.IfExists("blcc://eth1.0/account/" + account + "/storage/ctrn-0/") {
+			t.Error("Error: Both commits should take effect", account)
+		}
+	}
+}
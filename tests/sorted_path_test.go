@@ -0,0 +1,94 @@
+package ccurltest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arcology-network/common-lib/common"
+	orderedset "github.com/arcology-network/common-lib/container/set"
+	ccurl "github.com/arcology-network/concurrenturl"
+	arbitrator "github.com/arcology-network/concurrenturl/arbitrator"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	indexer "github.com/arcology-network/concurrenturl/indexer"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+)
+
+func TestSortedPathRangeReads(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	if _, err := url1.Write(1, root, commutative.NewSortedPath()); err != nil {
+		t.Error(err)
+	}
+
+	for _, key := range []string{"elem-3", "elem-1", "elem-5"} {
+		if _, err := url1.Write(1, root+key, noncommutative.NewString(key)); err != nil {
+			t.Error(err)
+		}
+	}
+	commitTransitions(url, []uint32{1}, url1)
+
+	url2 := ccurl.NewConcurrentUrl(store)
+	if _, err := url2.Write(2, root+"elem-2", noncommutative.NewString("elem-2")); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{2}, url2)
+
+	url3 := ccurl.NewConcurrentUrl(store)
+	if v, _ := url3.Read(3, root, new(commutative.Path)); !reflect.DeepEqual(v.(*orderedset.OrderedSet).Keys(), []string{"elem-1", "elem-2", "elem-3", "elem-5"}) {
+		t.Error("Error: The keys should be sorted", v.(*orderedset.OrderedSet).Keys())
+	}
+
+	if k, v, _, err := url3.LowerBound(3, root, "elem-4", new(noncommutative.String)); err != nil || k != "elem-5" || v.(string) != "elem-5" {
+		t.Error("Error: Wrong lower bound", k, v, err)
+	}
+
+	if k, v, _, err := url3.UpperBound(3, root, "elem-2", new(noncommutative.String)); err != nil || k != "elem-3" || v.(string) != "elem-3" {
+		t.Error("Error: Wrong upper bound", k, v, err)
+	}
+
+	keys, values, _, err := url3.Range(3, root, "elem-2", "elem-5", new(noncommutative.String))
+	if err != nil || !reflect.DeepEqual(keys, []string{"elem-2", "elem-3"}) || !reflect.DeepEqual(values, []interface{}{"elem-2", "elem-3"}) {
+		t.Error("Error: Wrong range", keys, values, err)
+	}
+
+	// The path and the visited elements are all recorded
+	accesses := indexer.Univalues(common.Clone(url3.Export(indexer.Sorter))).To(indexer.ITCAccess{})
+	for _, path := range []string{root, root + "elem-2", root + "elem-3", root + "elem-5"} {
+		if pos, _ := common.FindFirstIf(accesses, func(v interfaces.Univalue) bool { return *v.GetPath() == path && v.Reads() > 0 }); pos < 0 {
+			t.Error("Error: Missing the read record", path)
+		}
+	}
+
+	if pos, _ := common.FindFirstIf(accesses, func(v interfaces.Univalue) bool { return *v.GetPath() == root+"elem-1" }); pos >= 0 {
+		t.Error("Error: elem-1 wasn't visited")
+	}
+
+	// Conflicts with a transaction updating an element in range
+	url4 := ccurl.NewConcurrentUrl(store)
+	if _, err := url4.Write(4, root+"elem-3", noncommutative.NewString("new")); err != nil {
+		t.Error(err)
+	}
+
+	accesses4 := indexer.Univalues(common.Clone(url4.Export(indexer.Sorter))).To(indexer.ITCAccess{})
+	IDVec := append(common.Fill(make([]uint32, len(accesses)), 0), common.Fill(make([]uint32, len(accesses4)), 1)...)
+	if conflicts := (&arbitrator.Arbitrator{}).Detect(IDVec, append(accesses, accesses4...)); len(conflicts) != 1 {
+		t.Error("Error: There should be 1 conflict", len(conflicts))
+	}
+
+	// Not a sorted path
+	url.Write(5, "blcc://eth1.0/account/"+alice+"/storage/ctrn-1/", commutative.NewPath())
+	if _, _, _, err := url.Range(5, "blcc://eth1.0/account/"+alice+"/storage/ctrn-1/", "", "", new(noncommutative.String)); err == nil {
+		t.Error("Error: Should only work on a sorted path")
+	}
+}
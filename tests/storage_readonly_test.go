@@ -1,5 +1,18 @@
 package ccurltest
 
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cachedstorage "github.com/arcology-network/common-lib/cachedstorage"
+	codec "github.com/arcology-network/common-lib/codec"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+	storage "github.com/arcology-network/concurrenturl/storage"
+)
+
 // import (
 // 	"fmt"
 // 	"net/http"
@@ -153,3 +166,110 @@ package ccurltest
 // 	}
 // 	//readonlyClientProxy
 // }
+
+func TestReadonlyBatchGetRemote(t *testing.T) {
+	serverDataStore := cachedstorage.NewDataStore(nil, cachedstorage.NewCachePolicy(0, 1), cachedstorage.NewMemDB(), storage.Codec{}.Encode, storage.Codec{}.Decode)
+	for i := 0; i < 8; i++ {
+		serverDataStore.Inject(fmt.Sprint(i), noncommutative.NewInt64(int64(i)))
+	}
+
+	encoder := func(v interface{}) []byte { // Values not cached are still in the raw bytes
+		if buffer, ok := v.([]byte); ok {
+			return buffer
+		}
+		return storage.Codec{}.Encode("", v)
+	}
+	server := storage.NewReadonlyServer("", encoder, nil, serverDataStore)
+	httpServer := httptest.NewServer(http.HandlerFunc(server.Receive))
+	defer httpServer.Close()
+
+	client := storage.NewReadonlyClient(httpServer.URL, "/store", nil)
+	keys := []string{"0", "3", "missing", "7"}
+	values, err := client.BatchGet(keys)
+	if err != nil || len(values) != len(keys) {
+		t.Fatal("Error: Failed to batch get", err)
+	}
+
+	for i, key := range keys {
+		if key == "missing" {
+			if values[i] != nil {
+				t.Error("Error: Should be nil", values[i])
+			}
+			continue
+		}
+
+		v := storage.Codec{}.Decode(values[i], nil)
+		if v == nil || fmt.Sprint(int64(*v.(*noncommutative.Int64))) != key {
+			t.Error("Error: Wrong value", key, v)
+		}
+	}
+
+	// Consistent with the single key reads
+	for i, key := range keys {
+		single, err := client.Get(key)
+		if err != nil || !bytes.Equal(single, values[i]) && !(len(single) == 0 && values[i] == nil) {
+			t.Error("Error: Mismatch", key, err)
+		}
+	}
+
+	if values, err := client.BatchGet([]string{}); err != nil || len(values) != 0 {
+		t.Error("Error: Should be empty", err)
+	}
+
+	// Server unreachable
+	httpServer.Close()
+	if _, err := client.BatchGet(keys); err == nil {
+		t.Error("Error: Should fail")
+	}
+}
+
+func TestReadonlyBatchGetMalformed(t *testing.T) {
+	serverDataStore := cachedstorage.NewDataStore(nil, cachedstorage.NewCachePolicy(0, 1), cachedstorage.NewMemDB(), storage.Codec{}.Encode, storage.Codec{}.Decode)
+	server := storage.NewReadonlyServer("", func(v interface{}) []byte { return storage.Codec{}.Encode("", v) }, nil, serverDataStore)
+	httpServer := httptest.NewServer(http.HandlerFunc(server.Receive))
+	defer httpServer.Close()
+
+	post := func(body []byte) int {
+		resp, err := http.Post(httpServer.URL, "application/octet-stream", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A count far beyond the buffer length
+	if status := post([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}); status != http.StatusBadRequest {
+		t.Error("Error: Should reject the request", status)
+	}
+
+	// An offset out of range
+	if status := post([]byte{1, 0, 0, 0, 0xff, 0, 0, 0}); status != http.StatusBadRequest {
+		t.Error("Error: Should reject the request", status)
+	}
+
+	if status := post([]byte{1, 0}); status != http.StatusBadRequest {
+		t.Error("Error: Should reject the request", status)
+	}
+
+	// Over the size limit
+	limit := storage.MAX_REQUEST_SIZE
+	storage.MAX_REQUEST_SIZE = 16
+	defer func() { storage.MAX_REQUEST_SIZE = limit }()
+	if status := post(codec.Strings{"0123456789", "0123456789"}.Encode()); status != http.StatusRequestEntityTooLarge {
+		t.Error("Error: Should be too large", status)
+	}
+
+	// The malformed responses are rejected by the client
+	for _, response := range [][]byte{
+		{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0},
+		codec.Byteset{{1}, {0xff, 0xff, 0, 0, 0, 0, 0, 0}}.Encode(),
+		codec.Byteset{{1, 1}, codec.Byteset{{1}}.Encode()}.Encode(),
+	} {
+		fake := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) { writer.Write(response) }))
+		if _, err := storage.NewReadonlyClient(fake.URL, "/store", nil).BatchGet([]string{"0"}); err == nil {
+			t.Error("Error: Should reject the response", response)
+		}
+		fake.Close()
+	}
+}
@@ -0,0 +1,165 @@
+package ccurltest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arcology-network/common-lib/common"
+	ccurl "github.com/arcology-network/concurrenturl"
+	arbitrator "github.com/arcology-network/concurrenturl/arbitrator"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	indexer "github.com/arcology-network/concurrenturl/indexer"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+)
+
+func TestStructCodec(t *testing.T) {
+	in := noncommutative.NewStruct([]byte{1}, []byte{}, []byte{3, 4})
+	in.(*noncommutative.Struct).SetField(1, []byte{2})
+
+	buffer := in.Encode()
+	if len(buffer) != int(in.Size()) {
+		t.Error("Error: Size mismatch", len(buffer), in.Size())
+	}
+
+	out := (&noncommutative.Struct{}).Decode(buffer).(*noncommutative.Struct)
+	if !out.Equal(in) || !common.EqualArray(out.Touched(), []uint32{1}) {
+		t.Error("Error: Mismatch after decoding")
+	}
+
+	out = (&noncommutative.Struct{}).StorageDecode(in.StorageEncode()).(*noncommutative.Struct)
+	if !out.Equal(in) || len(out.Touched()) != 0 {
+		t.Error("Error: Mismatch after storage decoding")
+	}
+}
+
+func TestStructFieldLevelConflicts(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	path := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/rec"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := url.Write(ccurlcommon.SYSTEM, "blcc://eth1.0/account/"+alice+"/storage/ctrn-0/", commutative.NewPath()); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := url.Write(ccurlcommon.SYSTEM, path, noncommutative.NewStruct([]byte{1}, []byte{2}, []byte{3})); err != nil {
+		t.Error(err)
+	}
+
+	trans := indexer.Univalues(common.Clone(url.Export(indexer.Sorter))).To(indexer.ITCTransition{})
+	url.Import(indexer.Univalues{}.Decode(indexer.Univalues(trans).Encode()).(indexer.Univalues))
+	url.Sort()
+	url.Commit([]uint32{ccurlcommon.SYSTEM})
+
+	url1 := ccurl.NewConcurrentUrl(store)
+	if _, err := url1.WriteField(1, path, 0, []byte{11}); err != nil {
+		t.Error(err)
+	}
+
+	url2 := ccurl.NewConcurrentUrl(store)
+	if _, err := url2.WriteField(2, path, 1, []byte{22}); err != nil {
+		t.Error(err)
+	}
+
+	url3 := ccurl.NewConcurrentUrl(store)
+	if v, _, err := url3.ReadField(3, path, 0); err != nil || !bytes.Equal(v.([]byte), []byte{1}) {
+		t.Error("Error: Wrong field value", v, err)
+	}
+
+	if _, _, err := url3.ReadField(3, path, 3); err == nil {
+		t.Error("Error: The field index should be out of range")
+	}
+
+	accesses1 := indexer.Univalues(common.Clone(url1.Export(indexer.Sorter))).To(indexer.ITCAccess{})
+	accesses2 := indexer.Univalues(common.Clone(url2.Export(indexer.Sorter))).To(indexer.ITCAccess{})
+	accesses3 := indexer.Univalues(common.Clone(url3.Export(indexer.Sorter))).To(indexer.ITCAccess{})
+
+	// Different fields, no conflict
+	accesses := indexer.Univalues{}.Decode(indexer.Univalues(append(common.Clone(accesses1), common.Clone(accesses2)...)).Encode()).(indexer.Univalues)
+	IDVec := append(common.Fill(make([]uint32, len(accesses1)), 0), common.Fill(make([]uint32, len(accesses2)), 1)...)
+	conflicts := (&arbitrator.Arbitrator{}).Detect(IDVec, accesses)
+	if len(conflicts) != 0 {
+		t.Error("Error: There should be no conflict")
+	}
+
+	// The same field, one conflict
+	IDVec = append(common.Fill(make([]uint32, len(accesses1)), 0), common.Fill(make([]uint32, len(accesses3)), 1)...)
+	conflicts = (&arbitrator.Arbitrator{}).Detect(IDVec, append(common.Clone(accesses1), common.Clone(accesses3)...))
+	if len(conflicts) != 1 {
+		t.Error("Error: There should be 1 conflict")
+	}
+
+	// Both field updates should take effect
+	trans1 := indexer.Univalues(common.Clone(url1.Export(indexer.Sorter))).To(indexer.ITCTransition{})
+	trans2 := indexer.Univalues(common.Clone(url2.Export(indexer.Sorter))).To(indexer.ITCTransition{})
+	url.Import(indexer.Univalues{}.Decode(indexer.Univalues(append(trans1, trans2...)).Encode()).(indexer.Univalues))
+	url.Sort()
+	url.Commit([]uint32{1, 2})
+
+	v, _ := url.Read(4, path, new(noncommutative.Struct))
+	fields := v.([][]byte)
+	if !bytes.Equal(fields[0], []byte{11}) || !bytes.Equal(fields[1], []byte{22}) || !bytes.Equal(fields[2], []byte{3}) {
+		t.Error("Error: Wrong fields after commit", fields)
+	}
+
+	// A whole value read conflicts with any field write
+	url4 := ccurl.NewConcurrentUrl(store)
+	url4.Read(4, path, new(noncommutative.Struct))
+	accesses4 := indexer.Univalues(common.Clone(url4.Export(indexer.Sorter))).To(indexer.ITCAccess{})
+	IDVec = append(common.Fill(make([]uint32, len(accesses4)), 0), common.Fill(make([]uint32, len(accesses2)), 1)...)
+	conflicts = (&arbitrator.Arbitrator{}).Detect(IDVec, append([]interfaces.Univalue(accesses4), common.Clone(accesses2)...))
+	if len(conflicts) != 1 {
+		t.Error("Error: There should be 1 conflict")
+	}
+}
+
+func TestStructMissingAndMalformed(t *testing.T) {
+	in := noncommutative.NewStruct([]byte{1}, []byte{2})
+	if in.Equal(noncommutative.NewBytes([]byte{1})) {
+		t.Error("Error: A struct shouldn't equal to a non-struct value")
+	}
+
+	if v := (&noncommutative.Struct{}).StorageDecode([]byte{0xff, 0x01}); v != nil {
+		t.Error("Error: Malformed bytes should decode to nil", v)
+	}
+
+	store := chooseDataStore()
+	alice := AliceAccount()
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+
+	path := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/missing"
+	if _, _, err := url.ReadField(1, path, 0); err == nil {
+		t.Error("Error: Reading a field of a missing value should fail")
+	}
+
+	if _, err := url.WriteField(1, path, 0, []byte{1}); err == nil {
+		t.Error("Error: Writing a field of a missing value should fail")
+	}
+
+	for _, v := range url.Export(indexer.Sorter) {
+		if *v.GetPath() == path && (v.Reads() != 0 || v.Writes() != 0) {
+			t.Error("Error: No access should be recorded for a missing value", v.Reads(), v.Writes())
+		}
+	}
+}
+
+func TestStructRebase(t *testing.T) {
+	update := noncommutative.NewStruct([]byte{0}, []byte{0}, []byte{0}).(*noncommutative.Struct)
+	update.SetField(1, []byte{22})
+
+	update.Rebase(noncommutative.NewStruct([]byte{1}, []byte{2}, []byte{3}))
+	v, _, _ := update.Get()
+	fields := v.([][]byte)
+	if !bytes.Equal(fields[0], []byte{1}) || !bytes.Equal(fields[1], []byte{22}) || !bytes.Equal(fields[2], []byte{3}) {
+		t.Error("Error: Only the untouched fields should be taken from the committed value", fields)
+	}
+}
@@ -0,0 +1,250 @@
+package ccurltest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	orderedset "github.com/arcology-network/common-lib/container/set"
+	ccurl "github.com/arcology-network/concurrenturl"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	commutative "github.com/arcology-network/concurrenturl/commutative"
+	"github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+	storage "github.com/arcology-network/concurrenturl/storage"
+	univalue "github.com/arcology-network/concurrenturl/univalue"
+)
+
+func TestExpiringCodec(t *testing.T) {
+	in := noncommutative.NewExpiring(noncommutative.NewString("session"), 12)
+	if out := new(noncommutative.Expiring).Decode(in.Encode()); !in.Equal(out) {
+		t.Error("Error: Mismatch", out)
+	}
+
+	if out := new(noncommutative.Expiring).StorageDecode(in.StorageEncode()); out == nil || !in.Equal(out) {
+		t.Error("Error: Mismatch", out)
+	}
+
+	if out := new(noncommutative.Expiring).StorageDecode(noncommutative.NewString("session").StorageEncode()); out != nil {
+		t.Error("Error: Not an expiring value", out)
+	}
+
+	univ := univalue.NewUnivalue(1, "blcc://eth1.0/account/"+AliceAccount()+"/storage/ctrn-0/elem-0", 0, 1, 0, in, nil)
+	if out := (&univalue.Univalue{}).Decode(univ.Encode()).(*univalue.Univalue); !in.Equal(out.Value()) {
+		t.Error("Error: Mismatch", out.Value())
+	}
+}
+
+func TestExpiringValues(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store).SetHeight(100)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store).SetHeight(100)
+	url0.Write(0, root, commutative.NewPath())
+	if _, err := url0.WriteWithTTL(0, root+"session", noncommutative.NewString("alice"), 2); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := url0.WriteWithTTL(0, root+"lock", noncommutative.NewBytes([]byte{1}), 5); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := url0.WriteWithTTL(0, root+"counter", commutative.NewUnboundedUint64(), 5); err == nil {
+		t.Error("Error: The commutative values can't expire")
+	}
+	commitTransitions(url, []uint32{0}, url0)
+
+	url1 := ccurl.NewConcurrentUrl(store).SetHeight(101) // Still alive
+	if v, _ := url1.Read(1, root+"session", new(noncommutative.Expiring)); v == nil || v.(string) != "alice" {
+		t.Error("Error: Wrong value", v)
+	}
+
+	url2 := ccurl.NewConcurrentUrl(store).SetHeight(102) // Expired, but not swept yet
+	if v, _ := url2.Read(2, root+"session", new(noncommutative.Expiring)); v != nil {
+		t.Error("Error: Should have expired", v)
+	}
+
+	if url2.IfExists(root+"session") || !url2.IfExists(root+"lock") || !url2.IfExists(root) {
+		t.Error("Error: Wrong existence")
+	}
+
+	url.SetHeight(102) // The next commit sweeps the expired entries
+	url3 := ccurl.NewConcurrentUrl(store).SetHeight(102)
+	url3.Write(3, root+"elem-0", noncommutative.NewString("0"))
+	commitTransitions(url, []uint32{3}, url3)
+
+	url4 := ccurl.NewConcurrentUrl(store).SetHeight(102)
+	if v, _ := url4.Read(4, root, new(commutative.Path)); !reflect.DeepEqual(v.(*orderedset.OrderedSet).Keys(), []string{"lock", "elem-0"}) {
+		t.Error("Error: The expired key should be removed from the path", v.(*orderedset.OrderedSet).Keys())
+	}
+
+	if v, _ := url4.PeekCommitted(root+"session", new(noncommutative.Expiring)); v != nil {
+		t.Error("Error: The expired entry should be deleted", v)
+	}
+
+	// Refreshed before expiring
+	url5 := ccurl.NewConcurrentUrl(store).SetHeight(104)
+	if _, err := url5.WriteWithTTL(5, root+"lock", noncommutative.NewBytes([]byte{2}), 5); err != nil {
+		t.Error(err)
+	}
+	url.SetHeight(104)
+	commitTransitions(url, []uint32{5}, url5)
+
+	url.SetHeight(106) // Past the original expiry height
+	url6 := ccurl.NewConcurrentUrl(store).SetHeight(106)
+	url6.Write(6, root+"elem-1", noncommutative.NewString("1"))
+	commitTransitions(url, []uint32{6}, url6)
+
+	url7 := ccurl.NewConcurrentUrl(store).SetHeight(106)
+	if v, _ := url7.Read(7, root+"lock", new(noncommutative.Expiring)); v == nil || !reflect.DeepEqual(v, []byte{2}) {
+		t.Error("Error: The refreshed entry should be alive", v)
+	}
+}
+
+func TestExpiringSweptByAnotherInstance(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store).SetHeight(100)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store).SetHeight(100)
+	url0.Write(0, root, commutative.NewPath())
+	url0.WriteWithTTL(0, root+"session", noncommutative.NewString("alice"), 2)
+	url0.WriteWithTTL(0, root+"lock", noncommutative.NewString("alice"), 2)
+	commitTransitions(url, []uint32{0}, url0)
+
+	if due := store.(interfaces.ExpiryIndex).ExpiringAt(102); len(due[102]) != 2 {
+		t.Fatal("Error: The expiring values should be indexed in the datastore", due)
+	}
+
+	// A new instance, as after a restart, sweeps the values committed by the other one.
+	restarted := ccurl.NewConcurrentUrl(store).SetHeight(102)
+	url1 := ccurl.NewConcurrentUrl(store).SetHeight(102)
+	url1.Write(1, root+"lock", noncommutative.NewExpiring(noncommutative.NewString("bob"), 110)) // Refreshed in the same block
+	commitTransitions(restarted, []uint32{1}, url1)
+
+	if v, _ := restarted.PeekCommitted(root+"session", new(noncommutative.Expiring)); v != nil {
+		t.Error("Error: The expired entry should be deleted", v)
+	}
+
+	if v, _ := restarted.Read(2, root, new(commutative.Path)); !reflect.DeepEqual(v.(*orderedset.OrderedSet).Keys(), []string{"lock"}) {
+		t.Error("Error: The expired key should be removed from the path", v.(*orderedset.OrderedSet).Keys())
+	}
+
+	due := store.(interfaces.ExpiryIndex).ExpiringAt(110)
+	if _, ok := due[102]; ok {
+		t.Error("Error: The index entry should be removed", due)
+	}
+
+	if !reflect.DeepEqual(due[110], []string{root + "lock"}) {
+		t.Error("Error: The refreshed entry should be indexed again", due)
+	}
+
+	// The index is kept out of the state.
+	keys, _ := store.(*storage.EthDataStore).Dump()
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "blcc://eth1.0/account/"+alice) {
+			t.Error("Error: Only the account of Alice should be in the state", key)
+		}
+	}
+}
+
+func TestExpiredValueReplaced(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store).SetHeight(100)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store).SetHeight(100)
+	url0.Write(0, root, commutative.NewPath())
+	url0.WriteWithTTL(0, root+"session", noncommutative.NewString("alice"), 2)
+	commitTransitions(url, []uint32{0}, url0)
+
+	url1 := ccurl.NewConcurrentUrl(store).SetHeight(101)
+	if _, err := url1.Write(1, root+"session", noncommutative.NewBytes([]byte{1})); err == nil {
+		t.Error("Error: A live expiring value can only be replaced by another expiring value")
+	}
+
+	url2 := ccurl.NewConcurrentUrl(store).SetHeight(102)
+	if _, err := url2.Write(2, root+"session", noncommutative.NewBytes([]byte{2})); err != nil {
+		t.Error("Error: An expired value should be replaceable by any type", err)
+	}
+	url.SetHeight(102)
+	commitTransitions(url, []uint32{2}, url2)
+
+	url3 := ccurl.NewConcurrentUrl(store).SetHeight(103)
+	if v, _ := url3.Read(3, root+"session", new(noncommutative.Bytes)); !reflect.DeepEqual(v, []byte{2}) {
+		t.Error("Error: Wrong value", v)
+	}
+
+	if v, _ := store.Retrive(root+"session", new(noncommutative.Expiring)); v != nil {
+		t.Error("Error: Not an expiring value any more", v)
+	}
+}
+
+// A struct laid out like the old tagged encoding is still a struct.
+func TestExpiringLookalike(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	url.Write(ccurlcommon.SYSTEM, root, commutative.NewPath())
+	url.Write(ccurlcommon.SYSTEM, root+"rec", noncommutative.NewStruct([]byte{noncommutative.EXPIRING}, []byte{1}, []byte{noncommutative.STRING}, []byte{2}))
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	v, _ := store.Retrive(root+"rec", new(noncommutative.Struct))
+	if _, ok := v.(*noncommutative.Struct); !ok {
+		t.Error("Error: Should be a struct", v)
+	}
+}
+
+func TestExpiringReadWithWrappedType(t *testing.T) {
+	store := chooseDataStore()
+	alice := AliceAccount()
+	root := "blcc://eth1.0/account/" + alice + "/storage/ctrn-0/"
+
+	url := ccurl.NewConcurrentUrl(store).SetHeight(100)
+	if _, err := url.NewAccount(ccurlcommon.SYSTEM, alice); err != nil {
+		t.Error(err)
+	}
+	commitTransitions(url, []uint32{ccurlcommon.SYSTEM}, url)
+
+	url0 := ccurl.NewConcurrentUrl(store).SetHeight(100)
+	url0.Write(0, root, commutative.NewPath())
+	url0.WriteWithTTL(0, root+"session", noncommutative.NewString("alice"), 2)
+	commitTransitions(url, []uint32{0}, url0)
+
+	// The stored type is checked, not the one asked for.
+	if v, _ := store.Retrive(root+"session", new(noncommutative.String)); v == nil || v.(*noncommutative.Expiring).Expiry() != 102 {
+		t.Error("Error: Should be an expiring value", v)
+	}
+
+	if v, _ := ccurl.NewConcurrentUrl(store).SetHeight(101).Read(1, root+"session", new(noncommutative.String)); v == nil || v.(string) != "alice" {
+		t.Error("Error: Wrong value", v)
+	}
+
+	if v, _ := ccurl.NewConcurrentUrl(store).SetHeight(102).Read(2, root+"session", new(noncommutative.String)); v != nil {
+		t.Error("Error: Should have expired", v)
+	}
+}
@@ -0,0 +1,126 @@
+package concurrenturl
+
+import (
+	"errors"
+
+	"github.com/arcology-network/common-lib/common"
+	ccmap "github.com/arcology-network/common-lib/container/map"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
+	indexer "github.com/arcology-network/concurrenturl/indexer"
+	interfaces "github.com/arcology-network/concurrenturl/interfaces"
+	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+	"github.com/arcology-network/concurrenturl/univalue"
+)
+
+// Set the current block height, the expiring values at or below it are treated as absent.
+func (this *ConcurrentUrl) SetHeight(height uint64) *ConcurrentUrl {
+	this.height = height
+	return this
+}
+
+func (this *ConcurrentUrl) Height() uint64 { return this.height }
+
+// Write a noncommutative value expiring after ttl blocks from the current height. The value can be read
+// with either an *Expiring or the type of the wrapped value.
+func (this *ConcurrentUrl) WriteWithTTL(tx uint32, path string, value interface{}, ttl uint64) (int64, error) {
+	if value == nil || value.(interfaces.Type).IsCommutative() {
+		return 0, errors.New("Error: Only the noncommutative values can expire")
+	}
+	return this.Write(tx, path, noncommutative.NewExpiring(value.(interfaces.Type), this.height+ttl))
+}
+
+func (this *ConcurrentUrl) isExpired(path string) bool {
+	v, _ := this.writeCache.Peek(path, new(noncommutative.Expiring))
+	expiring, ok := v.(*noncommutative.Expiring)
+	return ok && expiring.IsExpired(this.height)
+}
+
+// If the key is updated in the current block.
+func (this *ConcurrentUrl) isUpdated(key string) bool {
+	if v, _ := this.importer.ByPath().(*ccmap.ConcurrentMap).Get(key); v != nil {
+		return true
+	}
+
+	v, _ := this.imuImporter.ByPath().(*ccmap.ConcurrentMap).Get(key)
+	return v != nil
+}
+
+// The expiry index is kept by the datastore, outside of the state. The expired values in a datastore without
+// one are only treated as absent, they won't be swept.
+func (this *ConcurrentUrl) expiryIndex() interfaces.ExpiryIndex {
+	index, _ := this.importer.Store().(interfaces.ExpiryIndex)
+	return index
+}
+
+// Delete the expired values in the index entries up to the current height, and remove them from their parent paths.
+// The deletions are reassigned to the system afterwards, so they will take effect regardless of the conflict status.
+// It returns the index entries consumed, with the keys that are updated in the current block and still need checking.
+func (this *ConcurrentUrl) sweep() map[uint64][]string {
+	index := this.expiryIndex()
+	if index == nil {
+		return nil
+	}
+
+	due := index.ExpiringAt(this.height)
+	if len(due) == 0 {
+		return nil
+	}
+
+	sweeper := NewConcurrentUrl(this.importer.Store()).SetHeight(this.height)
+	consumed := map[uint64][]string{}
+	for height, keys := range due {
+		consumed[height] = []string{}
+		for _, key := range keys {
+			if this.isUpdated(key) {
+				consumed[height] = append(consumed[height], key)
+				continue
+			}
+
+			v, _ := this.importer.Store().Retrive(key, new(noncommutative.Expiring))
+			if expiring, ok := v.(*noncommutative.Expiring); ok && expiring.IsExpired(this.height) { // Not refreshed or replaced
+				sweeper.Write(0, key, nil)
+			}
+		}
+	}
+
+	deletions := indexer.Univalues(common.Clone(sweeper.Export(indexer.Sorter))).To(indexer.ITCTransition{})
+	common.Foreach(deletions, func(v *interfaces.Univalue, _ int) { (*v).SetTx(ccurlcommon.SYSTEM) })
+	this.importer.Import(deletions)
+	this.importer.SortDeltaSequences()
+	return consumed
+}
+
+// Add the expiring values being committed to the index and remove the entries consumed by the sweep.
+func (this *ConcurrentUrl) indexExpiring(consumed map[uint64][]string) error {
+	index := this.expiryIndex()
+	if index == nil {
+		return nil
+	}
+
+	entries, committed := map[uint64][]string{}, map[string]bool{}
+	for _, importer := range []*indexer.Importer{this.importer, this.imuImporter} {
+		keys, values := importer.KVs()
+		for i, key := range keys {
+			if len(consumed) > 0 {
+				committed[key] = true
+			}
+
+			if expiring, ok := values[i].(*univalue.Univalue).Value().(*noncommutative.Expiring); ok {
+				entries[expiring.Expiry()] = append(entries[expiring.Expiry()], key)
+			}
+		}
+	}
+
+	for height, keys := range consumed { // The ones not updated after all need checking in the next sweep
+		for _, key := range keys {
+			if !committed[key] {
+				entries[height] = append(entries[height], key)
+			}
+		}
+	}
+
+	if len(entries) == 0 && len(consumed) == 0 {
+		return nil
+	}
+	return index.UpdateExpiring(common.MapKeys(consumed), entries)
+}
@@ -1,6 +1,11 @@
 package univalue
 
-import "github.com/arcology-network/concurrenturl/interfaces"
+import (
+	"sort"
+
+	"github.com/arcology-network/common-lib/common"
+	"github.com/arcology-network/concurrenturl/interfaces"
+)
 
 type Unimeta struct {
 	vType       uint8
@@ -11,9 +16,22 @@ type Unimeta struct {
 	writes      uint32
 	deltaWrites uint32
 	preexists   bool
+	fields      []FieldAccess // Field level accesses, only for the values implementing interfaces.FieldAccessor
+	lengthReads uint32        // Reads of the container length only
+	existReads  uint32        // Reads checking if some keys exist in the container only
+	keyReads    []string      // The keys checked by the existence reads, sorted
+	keyWrites   []string      // The keys added or removed by the delta writes to a container, sorted
+	lengthDelta int32         // Length change caused by the delta writes to a container
 	reclaimFunc func(interface{})
 }
 
+// Reads and writes to a single field of a value
+type FieldAccess struct {
+	Idx    uint32
+	Reads  uint32
+	Writes uint32
+}
+
 func NewUnimeta(tx uint32, key string, reads, writes uint32, deltaWrites uint32, vType uint8, persistent, preexists bool) *Unimeta {
 	return &Unimeta{
 		vType:       vType,
@@ -31,6 +49,15 @@ func (this *Unimeta) Merge(other *Unimeta) {
 	this.writes += other.writes
 	this.deltaWrites += other.deltaWrites
 	this.persistent = this.persistent || other.persistent
+	for _, v := range other.fields {
+		this.AddFieldAccess(v.Idx, v.Reads, v.Writes)
+	}
+
+	this.lengthReads += other.lengthReads
+	this.existReads += other.existReads
+	for _, key := range other.keyReads {
+		this.addKeyRead(key)
+	}
 }
 
 func (this *Unimeta) GetPersistent() bool  { return this.persistent }
@@ -53,6 +80,83 @@ func (this *Unimeta) IncrementReads(reads uint32)             { this.reads += re
 func (this *Unimeta) IncrementWrites(writes uint32)           { this.writes += writes }
 func (this *Unimeta) IncrementDeltaWrites(deltaWrites uint32) { this.deltaWrites += deltaWrites }
 
+func (this *Unimeta) AddFieldAccess(idx, reads, writes uint32) {
+	pos := sort.Search(len(this.fields), func(i int) bool { return this.fields[i].Idx >= idx })
+	if pos < len(this.fields) && this.fields[pos].Idx == idx {
+		this.fields[pos].Reads += reads
+		this.fields[pos].Writes += writes
+		return
+	}
+
+	this.fields = append(this.fields, FieldAccess{})
+	copy(this.fields[pos+1:], this.fields[pos:])
+	this.fields[pos] = FieldAccess{idx, reads, writes}
+}
+
+func (this *Unimeta) FieldAccesses() []FieldAccess { return this.fields }
+
+// All the accesses to the value are field level ones, no whole value reads or writes.
+func (this *Unimeta) IsFieldLevel() bool {
+	if len(this.fields) == 0 || this.deltaWrites > 0 {
+		return false
+	}
+
+	reads := common.Accumulate(this.fields, uint32(0), func(v FieldAccess) uint32 { return v.Reads })
+	writes := common.Accumulate(this.fields, uint32(0), func(v FieldAccess) uint32 { return v.Writes })
+	return reads == this.reads && writes == this.writes
+}
+
+func (this *Unimeta) FieldReads() []uint32 {
+	return common.CopyIfDo(this.fields, func(v FieldAccess) bool { return v.Reads > 0 }, func(v FieldAccess) uint32 { return v.Idx })
+}
+
+func (this *Unimeta) FieldWrites() []uint32 {
+	return common.CopyIfDo(this.fields, func(v FieldAccess) bool { return v.Writes > 0 }, func(v FieldAccess) uint32 { return v.Idx })
+}
+
+// Only the length of the container was read, counted in the reads as well.
+func (this *Unimeta) AddLengthRead() {
+	this.reads++
+	this.lengthReads++
+}
+
+// Only the existence of a key was checked, counted in the reads as well.
+func (this *Unimeta) AddKeyRead(key string) {
+	this.reads++
+	this.existReads++
+	this.addKeyRead(key)
+}
+
+func (this *Unimeta) addKeyRead(key string) {
+	pos := sort.SearchStrings(this.keyReads, key)
+	if pos < len(this.keyReads) && this.keyReads[pos] == key {
+		return
+	}
+
+	this.keyReads = append(this.keyReads, "")
+	copy(this.keyReads[pos+1:], this.keyReads[pos:])
+	this.keyReads[pos] = key
+}
+
+func (this *Unimeta) LengthReads() uint32 { return this.lengthReads }
+func (this *Unimeta) KeyReads() []string  { return this.keyReads }
+func (this *Unimeta) KeyWrites() []string { return this.keyWrites }
+func (this *Unimeta) LengthDelta() int32  { return this.lengthDelta }
+
+// Keep the keys changed by the delta writes, so the partial reads can be checked without the container value.
+func (this *Unimeta) SetKeyWrites(added, removed []string) {
+	this.keyWrites = append(common.Clone(added), removed...)
+	sort.Strings(this.keyWrites)
+	this.lengthDelta = int32(len(added)) - int32(len(removed))
+}
+
+// All the reads are either length or key existence reads, and there is no write.
+func (this *Unimeta) IsPartialRead() bool {
+	return this.lengthReads+this.existReads > 0 &&
+		this.reads == this.lengthReads+this.existReads &&
+		this.writes == 0 && this.deltaWrites == 0
+}
+
 func (this *Unimeta) IsReadOnly() bool { return this.Writes() == 0 && this.DeltaWrites() == 0 }
 func (this *Unimeta) Preexist() bool   { return this.preexists } // Exist in cache as a failed read
 func (this *Unimeta) Persistent() bool { return this.persistent }
@@ -67,7 +171,13 @@ func (this *Unimeta) Equal(other *Unimeta) bool {
 		*this.path == *other.path &&
 		this.reads == other.reads &&
 		this.writes == other.writes &&
-		this.deltaWrites == other.deltaWrites
+		this.deltaWrites == other.deltaWrites &&
+		common.EqualArray(this.fields, other.fields) &&
+		this.lengthReads == other.lengthReads &&
+		this.existReads == other.existReads &&
+		common.EqualArray(this.keyReads, other.keyReads) &&
+		common.EqualArray(this.keyWrites, other.keyWrites) &&
+		this.lengthDelta == other.lengthDelta
 }
 
 func (this *Unimeta) Clone() Unimeta {
@@ -79,6 +189,12 @@ func (this *Unimeta) Clone() Unimeta {
 		deltaWrites: this.deltaWrites,
 		writes:      this.writes,
 		preexists:   this.preexists,
+		fields:      common.Clone(this.fields),
+		lengthReads: this.lengthReads,
+		existReads:  this.existReads,
+		keyReads:    common.Clone(this.keyReads),
+		keyWrites:   common.Clone(this.keyWrites),
+		lengthDelta: this.lengthDelta,
 		reclaimFunc: this.reclaimFunc,
 	}
 }
@@ -1,7 +1,6 @@
 package univalue
 
 import (
-	"bytes"
 	"reflect"
 
 	codec "github.com/arcology-network/common-lib/codec"
@@ -14,7 +13,7 @@ func (this *Unimeta) Encode() []byte {
 }
 
 func (this *Unimeta) HeaderSize() uint32 {
-	return uint32(9 * codec.UINT32_LEN)
+	return uint32(15 * codec.UINT32_LEN)
 }
 
 func (this *Unimeta) Size() uint32 {
@@ -26,7 +25,13 @@ func (this *Unimeta) Size() uint32 {
 		uint32(4) + // codec.Uint32(this.writes).Size() +
 		uint32(4) + // codec.Uint32(this.deltaWrites).Size() +
 		uint32(1) + //+  codec.Bool(this.preexists).Size() +
-		uint32(1) //+  codec.Bool(this.persistent).Size() +
+		uint32(1) + //+  codec.Bool(this.persistent).Size() +
+		uint32(len(this.fields)*3*codec.UINT32_LEN) + // field level accesses
+		uint32(4) + // codec.Uint32(this.lengthReads).Size() +
+		uint32(4) + // codec.Uint32(this.existReads).Size() +
+		codec.Strings(this.keyReads).Size() +
+		codec.Strings(this.keyWrites).Size() +
+		uint32(8) // codec.Int64(this.lengthDelta).Size()
 }
 
 func (this *Unimeta) FillHeader(buffer []byte) int {
@@ -41,6 +46,12 @@ func (this *Unimeta) FillHeader(buffer []byte) int {
 			codec.Uint32(this.deltaWrites).Size(),
 			codec.Bool(this.preexists).Size(),
 			codec.Bool(this.persistent).Size(),
+			uint32(len(this.fields) * 3 * codec.UINT32_LEN),
+			codec.Uint32(this.lengthReads).Size(),
+			codec.Uint32(this.existReads).Size(),
+			codec.Strings(this.keyReads).Size(),
+			codec.Strings(this.keyWrites).Size(),
+			codec.Int64(this.lengthDelta).Size(),
 		},
 	)
 }
@@ -55,6 +66,12 @@ func (this *Unimeta) EncodeToBuffer(buffer []byte) int {
 	offset += codec.Uint32(this.deltaWrites).EncodeToBuffer(buffer[offset:])
 	offset += codec.Bool(this.preexists).EncodeToBuffer(buffer[offset:])
 	offset += codec.Bool(this.persistent).EncodeToBuffer(buffer[offset:])
+	offset += codec.Uint32s(this.fieldsToUint32s()).EncodeToBuffer(buffer[offset:])
+	offset += codec.Uint32(this.lengthReads).EncodeToBuffer(buffer[offset:])
+	offset += codec.Uint32(this.existReads).EncodeToBuffer(buffer[offset:])
+	offset += codec.Strings(this.keyReads).EncodeToBuffer(buffer[offset:])
+	offset += codec.Strings(this.keyWrites).EncodeToBuffer(buffer[offset:])
+	offset += codec.Int64(this.lengthDelta).EncodeToBuffer(buffer[offset:])
 
 	return offset
 }
@@ -67,7 +84,7 @@ func (this *Unimeta) Decode(buffer []byte) interface{} {
 
 	this.vType = uint8(reflect.Kind(codec.Uint8(1).Decode(fields[0]).(codec.Uint8)))
 	this.tx = uint32(codec.Uint32(0).Decode(fields[1]).(codec.Uint32))
-	key := string(codec.String("").Decode(bytes.Clone(fields[2])).(codec.String))
+	key := string(codec.String("").Decode(fields[2]).(codec.String)) // Copied already
 	this.path = &key
 	this.reads = uint32(codec.Uint32(1).Decode(fields[3]).(codec.Uint32))
 	this.writes = uint32(codec.Uint32(1).Decode(fields[4]).(codec.Uint32))
@@ -75,9 +92,41 @@ func (this *Unimeta) Decode(buffer []byte) interface{} {
 	this.preexists = bool(codec.Bool(false).Decode(fields[6]).(codec.Bool))
 	this.persistent = bool(codec.Bool(true).Decode(fields[7]).(codec.Bool))
 
+	this.fields = this.fields[:0]
+	if len(fields) > 8 {
+		this.fieldsFromUint32s(codec.Uint32s{}.Decode(fields[8]).(codec.Uint32s))
+	}
+
+	this.lengthReads, this.existReads, this.keyReads = 0, 0, this.keyReads[:0]
+	if len(fields) > 11 {
+		this.lengthReads = uint32(codec.Uint32(0).Decode(fields[9]).(codec.Uint32))
+		this.existReads = uint32(codec.Uint32(0).Decode(fields[10]).(codec.Uint32))
+		this.keyReads = append(this.keyReads, codec.Strings{}.Decode(fields[11]).(codec.Strings)...)
+	}
+
+	this.keyWrites, this.lengthDelta = this.keyWrites[:0], 0
+	if len(fields) > 13 {
+		this.keyWrites = append(this.keyWrites, codec.Strings{}.Decode(fields[12]).(codec.Strings)...)
+		this.lengthDelta = int32(codec.Int64(0).Decode(fields[13]).(codec.Int64))
+	}
 	return this
 }
 
+// Flatten the field level accesses into (idx, reads, writes) triples.
+func (this *Unimeta) fieldsToUint32s() []uint32 {
+	buffer := make([]uint32, 0, len(this.fields)*3)
+	for _, v := range this.fields {
+		buffer = append(buffer, v.Idx, v.Reads, v.Writes)
+	}
+	return buffer
+}
+
+func (this *Unimeta) fieldsFromUint32s(buffer []uint32) {
+	for i := 0; i+2 < len(buffer); i += 3 {
+		this.fields = append(this.fields, FieldAccess{buffer[i], buffer[i+1], buffer[i+2]})
+	}
+}
+
 func (this *Unimeta) GobEncode() ([]byte, error) {
 	return this.Encode(), nil
 }
@@ -92,5 +141,11 @@ func (this *Unimeta) GobDecode(data []byte) error {
 	this.reads = v.reads
 	this.writes = v.writes
 	this.deltaWrites = v.deltaWrites
+	this.fields = v.fields
+	this.lengthReads = v.lengthReads
+	this.existReads = v.existReads
+	this.keyReads = v.keyReads
+	this.keyWrites = v.keyWrites
+	this.lengthDelta = v.lengthDelta
 	return nil
 }
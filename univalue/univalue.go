@@ -67,11 +67,19 @@ func (this *Univalue) Init(tx uint32, key string, reads, writes, deltaWrites uin
 	this.reads = reads
 	this.writes = writes
 	this.deltaWrites = deltaWrites
+	this.fields = this.fields[:0]
+	this.lengthReads, this.existReads, this.keyReads = 0, 0, this.keyReads[:0]
+	this.keyWrites, this.lengthDelta = this.keyWrites[:0], 0
 	this.value = v
 	this.preexists = common.IfThenDo1st(len(args) > 0, func() bool { return (&Unimeta{}).CheckPreexist(key, args[0]) }, false)
 	return this
 }
 
+func (this *Univalue) SetReclaimFunc(put func(interface{})) *Univalue {
+	this.reclaimFunc = put
+	return this
+}
+
 func (this *Univalue) Reclaim() {
 	if this.reclaimFunc != nil {
 		this.reclaimFunc(this)
@@ -111,6 +119,83 @@ func (this *Univalue) Merge(writeCache interfaces.WriteCache) {
 	univ.(interfaces.Univalue).IncrementReads(readsDiff)
 	univ.(interfaces.Univalue).IncrementWrites(writesDiff)
 	univ.(interfaces.Univalue).IncrementDeltaWrites(deltaWriteDiff)
+
+	if len(this.fields) > 0 { // Keep the field level access records
+		univ.(interfaces.Univalue).GetUnimeta().(*Unimeta).fields = common.Clone(this.fields)
+	}
+
+	if this.lengthReads+this.existReads > 0 { // Keep the partial read records
+		meta := univ.(interfaces.Univalue).GetUnimeta().(*Unimeta)
+		meta.lengthReads, meta.existReads, meta.keyReads = this.lengthReads, this.existReads, common.Clone(this.keyReads)
+	}
+}
+
+// Read the length of a container only, the writes keeping the length unchanged won't conflict with it.
+func (this *Univalue) Length(tx uint32) int {
+	container, ok := this.value.(interfaces.Container)
+	if !ok {
+		this.IncrementReads(1) // Not a container, a full read
+		return 0
+	}
+
+	this.AddLengthRead()
+	return container.Length()
+}
+
+// Check if a key exists in a container only, only the writes adding or removing the key conflict with it.
+func (this *Univalue) Has(tx uint32, key string) bool {
+	container, ok := this.value.(interfaces.Container)
+	if !ok {
+		this.IncrementReads(1) // Not a container, a full read
+		return false
+	}
+
+	this.AddKeyRead(key)
+	return container.Has(key)
+}
+
+// Read a single field, the access is only recorded on that field.
+func (this *Univalue) GetField(tx uint32, idx uint32) (interface{}, error) {
+	if this.value == nil { // Nothing to read, no access is recorded
+		return nil, errors.New("Error: The value doesn't exist")
+	}
+
+	accessor, ok := this.value.(interfaces.FieldAccessor)
+	if !ok {
+		this.IncrementReads(1)
+		return nil, errors.New("Error: The value doesn't support field level accesses")
+	}
+
+	v, err := accessor.GetField(idx)
+	if err == nil {
+		this.reads++
+		this.AddFieldAccess(idx, 1, 0)
+	}
+	return v, err
+}
+
+// Update a single field, the other fields are left untouched.
+func (this *Univalue) SetField(tx uint32, idx uint32, v interface{}) error {
+	this.tx = tx
+	if this.value == nil { // Only the fields of an existing value can be updated
+		return errors.New("Error: The value doesn't exist")
+	}
+
+	if _, ok := this.value.(interfaces.FieldAccessor); !ok {
+		this.writes++
+		return errors.New("Error: The value doesn't support field level accesses")
+	}
+
+	if this.writes == 0 { // Make a deep copy if haven't done so
+		this.value = this.value.(interfaces.Type).Clone()
+	}
+
+	err := this.value.(interfaces.FieldAccessor).SetField(idx, v)
+	if err == nil {
+		this.writes++
+		this.AddFieldAccess(idx, 0, 1)
+	}
+	return err
 }
 
 func (this *Univalue) Set(tx uint32, path string, typedV interface{}, indexer interface{}) error { // update the value
@@ -140,7 +225,7 @@ func (this *Univalue) Set(tx uint32, path string, typedV interface{}, indexer in
 	this.reads += r
 	this.deltaWrites += dw
 
-	if typedV == nil && this.Value().(interfaces.Type).IsSelf(path) { // Delete the entry but keep the access record.
+	if typedV == nil && path == *this.path && this.Value().(interfaces.Type).IsSelf(path) { // Delete the entry but keep the access record, not for removing a nested child path.
 		this.vType = uint8(reflect.Invalid)
 		this.value = typedV // Delete the value
 		this.writes++
@@ -1,8 +1,11 @@
 package univalue
 
 import (
+	"errors"
+
 	codec "github.com/arcology-network/common-lib/codec"
 	"github.com/arcology-network/common-lib/common"
+	ccurlcommon "github.com/arcology-network/concurrenturl/common"
 	"github.com/arcology-network/concurrenturl/interfaces"
 	storage "github.com/arcology-network/concurrenturl/storage"
 )
@@ -52,16 +55,56 @@ func (this *Univalue) EncodeToBuffer(buffer []byte) int {
 	return offset
 }
 
-func (this *Univalue) Decode(buffer []byte) interface{} {
+// The sizes of the fixed length meta fields, the others are checked by their layouts.
+var metaFieldSizes = map[int]int{0: 1, 1: 4, 3: 4, 4: 4, 5: 4, 6: 1, 7: 1, 9: 4, 10: 4, 13: 8}
+
+// CheckEncoding checks the layout of an encoded univalue and its meta fields, so the ones from the untrusted
+// sources can be decoded without going out of range.
+func (*Univalue) CheckEncoding(buffer []byte) error {
+	if ccurlcommon.CheckByteset(buffer) != nil {
+		return errors.New("Error: Malformed univalue")
+	}
+
 	fields := codec.Byteset{}.Decode(buffer).(codec.Byteset)
-	unimeta := (&Unimeta{}).Decode(fields[0]).(*Unimeta)
-	// v := (&storage.Codec{unimeta.vType}).Decode(fields[1])
+	if len(fields) != 2 || ccurlcommon.CheckByteset(fields[0]) != nil {
+		return errors.New("Error: Malformed univalue")
+	}
+
+	meta := codec.Byteset{}.Decode(fields[0]).(codec.Byteset)
+	if len(meta) != 1 && len(meta) < 8 {
+		return errors.New("Error: Wrong number of meta fields")
+	}
+
+	for i := range meta {
+		if size, ok := metaFieldSizes[i]; ok && len(meta) > 1 && len(meta[i]) != size {
+			return errors.New("Error: Malformed meta field")
+		}
+	}
 
-	return &Univalue{
-		*unimeta,
-		(&storage.Codec{unimeta.vType}).Decode(fields[1], this.value),
-		fields[1], // Keep copy, should expire as soon as the value is updated
+	if (len(meta) > 8 && len(meta[8])%(3*codec.UINT32_LEN) != 0) ||
+		(len(meta) > 11 && ccurlcommon.CheckByteset(meta[11]) != nil) ||
+		(len(meta) > 12 && ccurlcommon.CheckByteset(meta[12]) != nil) {
+		return errors.New("Error: Malformed meta field")
 	}
+
+	if len(meta) > 1 && len(fields[1]) > 0 && storage.TypeOf(meta[0][0]) == nil {
+		return errors.New("Error: Unknown value type")
+	}
+	return nil
+}
+
+func (this *Univalue) Decode(buffer []byte) interface{} {
+	return new(Univalue).DecodeTo(buffer)
+}
+
+// Decode into the existing object so a pooled one can be reused. The cache references the
+// input buffer directly, so the buffer must stay unchanged until the univalue is reclaimed.
+func (this *Univalue) DecodeTo(buffer []byte) *Univalue {
+	fields := codec.Byteset{}.Decode(buffer).(codec.Byteset)
+	this.Unimeta.Decode(fields[0])
+	this.value = storage.Codec{ID: this.vType}.Decode(fields[1], nil)
+	this.cache = fields[1] // No copy, should expire as soon as the value is updated
+	return this
 }
 
 func (this *Univalue) GetEncoded() []byte {
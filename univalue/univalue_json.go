@@ -0,0 +1,120 @@
+package univalue
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/arcology-network/concurrenturl/interfaces"
+	storage "github.com/arcology-network/concurrenturl/storage"
+)
+
+// The JSON layout for dumping, diffing and replaying the transitions and the access records.
+type univalueJSON struct {
+	Tx          uint32          `json:"tx"`
+	Path        string          `json:"path"`
+	Type        uint8           `json:"type"`
+	Reads       uint32          `json:"reads"`
+	Writes      uint32          `json:"writes"`
+	DeltaWrites uint32          `json:"deltaWrites"`
+	Preexists   bool            `json:"preexists"`
+	Persistent  bool            `json:"persistent"`
+	Fields      []FieldAccess   `json:"fields,omitempty"`
+	LengthReads uint32          `json:"lengthReads,omitempty"`
+	ExistReads  uint32          `json:"existReads,omitempty"`
+	KeyReads    []string        `json:"keyReads,omitempty"`
+	KeyWrites   []string        `json:"keyWrites,omitempty"`
+	LengthDelta int32           `json:"lengthDelta,omitempty"`
+	Value       json.RawMessage `json:"value"`
+}
+
+func (this *Univalue) MarshalJSON() ([]byte, error) {
+	value := json.RawMessage("null")
+	if this.value != nil {
+		buffer, err := json.Marshal(this.value)
+		if err != nil {
+			return nil, err
+		}
+		value = buffer
+	}
+
+	path := ""
+	if this.path != nil {
+		path = *this.path
+	}
+
+	return json.Marshal(univalueJSON{
+		Tx:          this.tx,
+		Path:        path,
+		Type:        this.vType,
+		Reads:       this.reads,
+		Writes:      this.writes,
+		DeltaWrites: this.deltaWrites,
+		Preexists:   this.preexists,
+		Persistent:  this.persistent,
+		Fields:      this.fields,
+		LengthReads: this.lengthReads,
+		ExistReads:  this.existReads,
+		KeyReads:    this.keyReads,
+		KeyWrites:   this.keyWrites,
+		LengthDelta: this.lengthDelta,
+		Value:       value,
+	})
+}
+
+func (this *Univalue) UnmarshalJSON(buffer []byte) error {
+	var v univalueJSON
+	if err := json.Unmarshal(buffer, &v); err != nil {
+		return err
+	}
+
+	var value interface{}
+	if len(v.Value) > 0 && string(v.Value) != "null" {
+		typed := storage.NewType(v.Type)
+		if typed == nil {
+			return errors.New("Error: Unknown value type")
+		}
+
+		if err := json.Unmarshal(v.Value, typed); err != nil {
+			return err
+		}
+		value = typed
+	}
+
+	*this = Univalue{
+		Unimeta: Unimeta{
+			vType:       v.Type,
+			persistent:  v.Persistent,
+			tx:          v.Tx,
+			path:        &v.Path,
+			reads:       v.Reads,
+			writes:      v.Writes,
+			deltaWrites: v.DeltaWrites,
+			preexists:   v.Preexists,
+			fields:      v.Fields,
+			lengthReads: v.LengthReads,
+			existReads:  v.ExistReads,
+			keyReads:    v.KeyReads,
+			keyWrites:   v.KeyWrites,
+			lengthDelta: v.LengthDelta,
+		},
+		value: value,
+		cache: []byte{},
+	}
+	return nil
+}
+
+// Decode a JSON array of univalues, the reverse of json.Marshal on a univalue slice.
+func UnivaluesFromJSON(buffer []byte) ([]interfaces.Univalue, error) {
+	var decoded []*Univalue
+	if err := json.Unmarshal(buffer, &decoded); err != nil {
+		return nil, err
+	}
+
+	univalues := make([]interfaces.Univalue, len(decoded))
+	for i, v := range decoded {
+		if v != nil {
+			univalues[i] = v
+		}
+	}
+	return univalues, nil
+}
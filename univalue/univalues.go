@@ -7,9 +7,7 @@ import (
 func UnivaluesDecode(bytesset [][]byte, get func() interface{}, put func(interface{})) []interfaces.Univalue {
 	univalues := make([]interfaces.Univalue, len(bytesset))
 	for i := range bytesset {
-		v := get().(*Univalue)
-		v.reclaimFunc = put
-		univalues[i] = v.Decode(bytesset[i]).(interfaces.Univalue)
+		univalues[i] = get().(*Univalue).DecodeTo(bytesset[i]).SetReclaimFunc(put)
 	}
 	return univalues
 }
@@ -2,6 +2,7 @@ package concurrenturl
 
 import (
 	"errors"
+	"io"
 	"math"
 	"reflect"
 
@@ -14,6 +15,7 @@ import (
 	indexer "github.com/arcology-network/concurrenturl/indexer"
 	interfaces "github.com/arcology-network/concurrenturl/interfaces"
 	noncommutative "github.com/arcology-network/concurrenturl/noncommutative"
+	storage "github.com/arcology-network/concurrenturl/storage"
 	"github.com/arcology-network/concurrenturl/univalue"
 )
 
@@ -22,6 +24,8 @@ type ConcurrentUrl struct {
 	importer    *indexer.Importer
 	imuImporter *indexer.Importer // transitions that will take effect anyway regardless of execution failures or conflicts
 	Platform    *ccurlcommon.Platform
+
+	height uint64 // The current block height, for the expiring values
 }
 
 func NewConcurrentUrl(store interfaces.Datastore) *ConcurrentUrl {
@@ -34,6 +38,16 @@ func NewConcurrentUrl(store interfaces.Datastore) *ConcurrentUrl {
 	}
 }
 
+// NewArchiveUrl opens a url on the state at a committed root for the historical reads, the writes
+// can't be committed. The release function unpins the root once the url is no longer used.
+func NewArchiveUrl(archive *storage.Archive, root [32]byte) (*ConcurrentUrl, func(), error) {
+	store, err := archive.At(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewConcurrentUrl(store), store.Release, nil
+}
+
 func (this *ConcurrentUrl) KVs() ([]string, []interface{}) {
 	keys, values := this.importer.KVs()
 	invKeys, invVals := this.imuImporter.KVs()
@@ -106,27 +120,7 @@ func (this *ConcurrentUrl) NewAccount(tx uint32, acct string) ([]interfaces.Univ
 
 	transitions := []interfaces.Univalue{}
 	for i, path := range paths {
-		var v interface{}
-		switch typeids[i] {
-		case commutative.PATH: // Path
-			v = commutative.NewPath()
-
-		case uint8(reflect.Kind(noncommutative.STRING)): // delta big int
-			v = noncommutative.NewString("")
-
-		case uint8(reflect.Kind(commutative.UINT256)): // delta big int
-			v = commutative.NewUnboundedU256()
-
-		case uint8(reflect.Kind(commutative.UINT64)):
-			v = commutative.NewUnboundedUint64()
-
-		case uint8(reflect.Kind(noncommutative.INT64)):
-			v = new(noncommutative.Int64)
-
-		case uint8(reflect.Kind(noncommutative.BYTES)):
-			v = noncommutative.NewBytes([]byte{})
-		}
-
+		v := storage.NewType(typeids[i])
 		if !this.writeCache.IfExists(path) {
 			transitions = append(transitions, univalue.NewUnivalue(tx, path, 0, 1, 0, v, nil))
 
@@ -143,7 +137,7 @@ func (this *ConcurrentUrl) NewAccount(tx uint32, acct string) ([]interfaces.Univ
 }
 
 func (this *ConcurrentUrl) IfExists(path string) bool {
-	return this.writeCache.IfExists(path)
+	return this.writeCache.IfExists(path) && !this.isExpired(path)
 }
 
 func (this *ConcurrentUrl) IndexOf(tx uint32, path string, key interface{}, T any) (uint64, uint64) {
@@ -176,6 +170,81 @@ func (this *ConcurrentUrl) KeyAt(tx uint32, path string, index interface{}, T an
 	return "", READ_NONEXIST
 }
 
+// Read the number of the elements under a path, only conflicts with the writes changing the length
+func (this *ConcurrentUrl) Length(tx uint32, path string) (uint64, uint64) {
+	if !common.IsPath(path) {
+		return 0, READ_NONEXIST
+	}
+
+	length, univ := this.writeCache.ReadLength(tx, path)
+	return uint64(length), Fee{}.Reader(univ.(interfaces.Univalue))
+}
+
+// Check if an element exists under a path, only conflicts with the writes adding or removing the same key
+func (this *ConcurrentUrl) HasKey(tx uint32, path string, key string) (bool, uint64) {
+	if !common.IsPath(path) {
+		return false, READ_NONEXIST
+	}
+
+	exists, univ := this.writeCache.ReadKeyExists(tx, path, key)
+	return exists, Fee{}.Reader(univ.(interfaces.Univalue))
+}
+
+// Search the keys of a sorted path, both the path and the elements found are recorded as read.
+func (this *ConcurrentUrl) searchSorted(tx uint32, path string, finder func(*commutative.Path) []string, T any) ([]string, []interface{}, uint64, error) {
+	if !common.IsPath(path) {
+		return nil, nil, READ_NONEXIST, errors.New("Error: Not a path!!!")
+	}
+
+	getter := func(v interface{}) (uint32, uint32, uint32, interface{}) { return 1, 0, 0, v }
+	v, _ := this.Do(tx, path, getter, new(commutative.Path))
+	pathInfo := v.(interfaces.Univalue).Value()
+	if !common.IsType[*commutative.Path](pathInfo) || !pathInfo.(*commutative.Path).IsSorted() {
+		return nil, nil, READ_NONEXIST, errors.New("Error: Not a sorted path")
+	}
+
+	keys := finder(pathInfo.(*commutative.Path))
+	values := make([]interface{}, len(keys))
+	fee := Fee{}.Reader(v.(interfaces.Univalue))
+	for i, key := range keys {
+		var readFee uint64
+		values[i], readFee = this.Read(tx, path+key, T)
+		fee += readFee
+	}
+	return keys, values, fee, nil
+}
+
+// The first element whose key is not less than the given one in a sorted path
+func (this *ConcurrentUrl) LowerBound(tx uint32, path string, key string, T any) (string, interface{}, uint64, error) {
+	keys, values, fee, err := this.searchSorted(tx, path, func(sorted *commutative.Path) []string {
+		k, ok := sorted.LowerBound(key)
+		return common.IfThen(ok, []string{k}, []string{})
+	}, T)
+
+	if err != nil || len(keys) == 0 {
+		return "", nil, fee, err
+	}
+	return keys[0], values[0], fee, nil
+}
+
+// The first element whose key is greater than the given one in a sorted path
+func (this *ConcurrentUrl) UpperBound(tx uint32, path string, key string, T any) (string, interface{}, uint64, error) {
+	keys, values, fee, err := this.searchSorted(tx, path, func(sorted *commutative.Path) []string {
+		k, ok := sorted.UpperBound(key)
+		return common.IfThen(ok, []string{k}, []string{})
+	}, T)
+
+	if err != nil || len(keys) == 0 {
+		return "", nil, fee, err
+	}
+	return keys[0], values[0], fee, nil
+}
+
+// All the elements whose keys are in [start, end) in a sorted path, an empty end means no upper limit
+func (this *ConcurrentUrl) Range(tx uint32, path string, start, end string, T any) ([]string, []interface{}, uint64, error) {
+	return this.searchSorted(tx, path, func(sorted *commutative.Path) []string { return sorted.Range(start, end) }, T)
+}
+
 func (this *ConcurrentUrl) Peek(path string, T any) (interface{}, uint64) {
 	typedv, univ := this.writeCache.Peek(path, T)
 	var v interface{}
@@ -193,13 +262,30 @@ func (this *ConcurrentUrl) PeekCommitted(path string, T any) (interface{}, uint6
 func (this *ConcurrentUrl) Read(tx uint32, path string, T any) (interface{}, uint64) {
 	typedv, univ := this.writeCache.Read(tx, path, T)
 	// fmt.Println("Read: ", path, "|", typedv)
+	if expiring, ok := univ.(interfaces.Univalue).Value().(*noncommutative.Expiring); ok && expiring.IsExpired(this.height) {
+		return nil, Fee{}.Reader(univ.(interfaces.Univalue)) // Expired, but still recorded as a read
+	}
 	return typedv, Fee{}.Reader(univ.(interfaces.Univalue))
 }
 
+// Read a single field of a struct, only conflicts with the transactions writing the same field
+func (this *ConcurrentUrl) ReadField(tx uint32, path string, idx uint32) (interface{}, uint64, error) {
+	v, univ, err := this.writeCache.ReadField(tx, path, idx, new(noncommutative.Struct))
+	return v, Fee{}.Reader(univ.(interfaces.Univalue)), err
+}
+
+// Write a single field of an existing struct
+func (this *ConcurrentUrl) WriteField(tx uint32, path string, idx uint32, value []byte) (int64, error) {
+	return int64(0), this.writeCache.WriteField(tx, path, idx, value, new(noncommutative.Struct))
+}
+
 func (this *ConcurrentUrl) Write(tx uint32, path string, value interface{}) (int64, error) {
 	// fmt.Println("Write: ", path, "|", value)
 	fee := int64(0) //Fee{}.Writer(path, value, this.writeCache)
 	if value == nil || (value != nil && value.(interfaces.Type).TypeID() != uint8(reflect.Invalid)) {
+		if _, ok := value.(*noncommutative.Expiring); value != nil && !ok && this.isExpired(path) { // Gone already, any type can take its place
+			this.writeCache.Write(tx, path, nil)
+		}
 		return fee, this.writeCache.Write(tx, path, value)
 	}
 
@@ -298,6 +384,35 @@ func (this *ConcurrentUrl) Import(transitions []interfaces.Univalue, args ...int
 	return this
 }
 
+// Decode the encoded transitions with the pooled univalues for importing. They reference the buffer
+// and will be reclaimed after Commit, so neither of them should be used afterwards.
+func (this *ConcurrentUrl) DecodeTransitions(buffer []byte) ([]interfaces.Univalue, error) {
+	return this.importer.Decode(buffer)
+}
+
+// ImportFrom imports the transitions from a stream written by indexer.UnivaluesWriter, chunkSize
+// transitions at a time, so a large block never has to be decoded into memory all at once.
+func (this *ConcurrentUrl) ImportFrom(reader io.Reader, chunkSize int, args ...interface{}) (int, error) {
+	if chunkSize <= 0 {
+		return 0, errors.New("Error: Chunk size must be positive!")
+	}
+
+	total := 0
+	stream := indexer.NewUnivaluesReader(reader)
+	for {
+		chunk, err := stream.ReadChunk(chunkSize)
+		if err == io.EOF {
+			return total, nil
+		}
+
+		if err != nil {
+			return total, err
+		}
+		this.Import(chunk, args...)
+		total += len(chunk)
+	}
+}
+
 // func (this *ConcurrentUrl) Snapshot(preTransitions []interfaces.Univalue) interfaces.Datastore {
 // 	// transitions := []interfaces.Univalue(indexer.Univalues(common.Clone(this.Export())).To(indexer.ITCTransition{}))
 // 	// transitions = append(transitions, preTransitions...)
@@ -343,6 +458,7 @@ func (this *ConcurrentUrl) WriteToDbBuffer() [32]byte {
 	invKeys, invVals := this.imuImporter.KVs()
 
 	keys, values = append(keys, invKeys...), append(values, invVals...)
+	this.importer.Store().UpdateCacheStats(values)
 	return this.importer.Store().Precommit(keys, values) // save the transitions to the DB buffer
 }
 
@@ -357,7 +473,9 @@ func (this *ConcurrentUrl) Commit(txs []uint32) *ConcurrentUrl {
 		this.Clear()
 		return this
 	}
+	consumed := this.sweep()
 	this.Finalize(txs)
+	this.indexExpiring(consumed)
 	this.WriteToDbBuffer() // Export transitions and save them to the DB buffer.
 	this.SaveToDB()
 	return this